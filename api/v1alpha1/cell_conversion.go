@@ -0,0 +1,146 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	pov1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+// ConversionDataAnnotation holds the v1beta1 spec of a Cell served as v1alpha1, so fields v1alpha1 can't
+// represent survive a round trip through it.
+const ConversionDataAnnotation = "monitoring.gitpod.io/conversion-data"
+
+// StatusConversionDataAnnotation holds the v1beta1 status of a Cell served as v1alpha1, so the status
+// v1alpha1 can't represent survives v1alpha1 clients writing the status back.
+const StatusConversionDataAnnotation = "monitoring.gitpod.io/status-conversion-data"
+
+// ConvertTo converts this Cell to the hub version (v1beta1).
+func (src *Cell) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Cell)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if data, ok := dst.Annotations[ConversionDataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &dst.Spec); err != nil {
+			return err
+		}
+		delete(dst.Annotations, ConversionDataAnnotation)
+	}
+	if data, ok := dst.Annotations[StatusConversionDataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &dst.Status); err != nil {
+			return err
+		}
+		delete(dst.Annotations, StatusConversionDataAnnotation)
+	}
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	dst.Spec.ClusterName = src.Spec.ClusterName
+	dst.Spec.Gitpod.Namespace = src.Spec.GitpodNamespace
+	dst.Spec.Metrics.RemoteWrite = remoteWritesToHub(src.Spec.Metrics.UpstreamRemoteWrites, dst.Spec.Metrics.RemoteWrite)
+	dst.Spec.Metrics.DropList = src.Spec.Metrics.Droplist
+	dst.Spec.Metrics.RemoteWriteAllowList = src.Spec.Metrics.UpstreamAllowlist
+	statusToHub(&src.Status, &dst.Status)
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1beta1) to this version.
+func (dst *Cell) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Cell)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	data, err := json.Marshal(src.Spec)
+	if err != nil {
+		return err
+	}
+	status, err := json.Marshal(src.Status)
+	if err != nil {
+		return err
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[ConversionDataAnnotation] = string(data)
+	dst.Annotations[StatusConversionDataAnnotation] = string(status)
+
+	dst.Spec.ClusterName = src.Spec.ClusterName
+	dst.Spec.GitpodNamespace = src.Spec.Gitpod.Namespace
+	dst.Spec.Metrics.UpstreamRemoteWrites = remoteWritesFromHub(src.Spec.Metrics.RemoteWrite)
+	dst.Spec.Metrics.Droplist = src.Spec.Metrics.DropList
+	dst.Spec.Metrics.UpstreamAllowlist = src.Spec.Metrics.RemoteWriteAllowList
+	statusFromHub(&src.Status, &dst.Status)
+
+	return nil
+}
+
+// remoteWritesToHub converts the remote-write endpoints, keeping the fields only v1beta1 has from the
+// endpoints restored from the conversion data.
+func remoteWritesToHub(src []pov1.RemoteWriteSpec, restored []v1beta1.RemoteWriteSpec) []v1beta1.RemoteWriteSpec {
+	if len(src) == 0 {
+		return nil
+	}
+
+	dst := make([]v1beta1.RemoteWriteSpec, len(src))
+	for i, rw := range src {
+		if i < len(restored) && restored[i].URL == rw.URL {
+			dst[i] = restored[i]
+		}
+		dst[i].RemoteWriteSpec = rw
+	}
+
+	return dst
+}
+
+// remoteWritesFromHub converts the remote-write endpoints. upstreamRemoteWrite is required in v1alpha1, so
+// a Cell without any is given an empty list that v1alpha1 clients can write back.
+func remoteWritesFromHub(src []v1beta1.RemoteWriteSpec) []pov1.RemoteWriteSpec {
+	dst := make([]pov1.RemoteWriteSpec, len(src))
+	for i, rw := range src {
+		dst[i] = rw.RemoteWriteSpec
+	}
+
+	return dst
+}
+
+// statusToHub converts the status, keeping the fields only v1beta1 has from the status restored from the
+// conversion data.
+func statusToHub(src *CellStatus, dst *v1beta1.CellStatus) {
+	dst.PrometheusOperatorReady = src.PrometheusOperatorReady
+	dst.PrometheusReady = src.PrometheusReady
+	dst.NodeExporterReady = src.NodeExporterReady
+	dst.KubeStateMetricsReady = src.KubeStateMetricsReady
+	dst.KubeletReady = src.KubeletReady
+	dst.APIServerReady = src.APIServerReady
+	dst.Conditions = src.Conditions
+}
+
+// statusFromHub converts the status, dropping the fields only v1beta1 has.
+func statusFromHub(src *v1beta1.CellStatus, dst *CellStatus) {
+	dst.PrometheusOperatorReady = src.PrometheusOperatorReady
+	dst.PrometheusReady = src.PrometheusReady
+	dst.NodeExporterReady = src.NodeExporterReady
+	dst.KubeStateMetricsReady = src.KubeStateMetricsReady
+	dst.KubeletReady = src.KubeletReady
+	dst.APIServerReady = src.APIServerReady
+	dst.Conditions = src.Conditions
+}
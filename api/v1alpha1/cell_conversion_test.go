@@ -0,0 +1,202 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	pov1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+
+	"github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("Cell conversion", func() {
+	var (
+		// Times are serialised with second precision and read back in the local time zone.
+		pausedUntil = metav1.NewTime(time.Unix(1672531200, 0))
+		status      = v1beta1.CellStatus{
+			PrometheusOperatorReady: pointer.Bool(true),
+			PrometheusReady:         pointer.Bool(false),
+			Conditions: []metav1.Condition{{
+				Type:               v1beta1.Ready,
+				Status:             metav1.ConditionFalse,
+				Reason:             "PrometheusNotReady",
+				LastTransitionTime: pausedUntil,
+			}},
+			// Only v1beta1 has these
+			DryRun: &v1beta1.DryRunStatus{
+				Time:    pausedUntil,
+				Changes: []v1beta1.ObjectChange{{Kind: "Deployment", Namespace: "monitoring", Name: "kube-state-metrics", Action: "Update", Fields: []string{"spec.replicas"}}},
+			},
+			Rollouts: []v1beta1.WorkloadRollout{{
+				Kind:          "Deployment",
+				Name:          "kube-state-metrics",
+				SpecHash:      "5d41402a",
+				Phase:         v1beta1.RolloutPhaseProgressing,
+				StartTime:     pausedUntil,
+				LastKnownGood: &runtime.RawExtension{Raw: []byte(`{"replicas":1}`)},
+			}},
+			Certificates: []v1beta1.CertificateStatus{{
+				SecretName: "cell-ca",
+				NotAfter:   pausedUntil,
+				RenewAfter: pausedUntil,
+			}},
+		}
+	)
+
+	hub := func() *v1beta1.Cell {
+		return &v1beta1.Cell{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "cell",
+				Namespace:   "monitoring",
+				Annotations: map[string]string{"owner": "platform"},
+			},
+			Spec: v1beta1.CellSpec{
+				ClusterName: "eu01",
+				Gitpod:      v1beta1.GitpodSpec{Namespace: "gitpod"},
+				Provider:    v1beta1.ProviderGKE,
+				Metrics: v1beta1.MetricsSpec{
+					RemoteWrite: []v1beta1.RemoteWriteSpec{
+						{
+							RemoteWriteSpec: pov1.RemoteWriteSpec{URL: "https://a.example.com/api/v1/write"},
+							Preset:          v1beta1.RemoteWritePresetLowLatency,
+						},
+						{
+							RemoteWriteSpec: pov1.RemoteWriteSpec{URL: "https://b.example.com/api/v1/write"},
+							AllowList:       []string{"up"},
+						},
+					},
+					DropList:             []string{"go_.*"},
+					RemoteWriteAllowList: []string{"gitpod_.*"},
+				},
+				ScrapeTLS:       v1beta1.ScrapeTLSSpec{Mode: v1beta1.ScrapeTLSVerify},
+				Paused:          true,
+				PausedUntil:     &pausedUntil,
+				RolloutDeadline: &metav1.Duration{Duration: 5 * time.Minute},
+			},
+			Status: status,
+		}
+	}
+
+	It("round-trips a v1beta1 Cell through v1alpha1", func() {
+		src := hub()
+
+		var old Cell
+		Expect(old.ConvertFrom(src)).To(Succeed())
+		Expect(old.Annotations).To(HaveKey(ConversionDataAnnotation))
+		Expect(old.Annotations).To(HaveKey(StatusConversionDataAnnotation))
+		Expect(old.Annotations).To(HaveKeyWithValue("owner", "platform"))
+
+		var dst v1beta1.Cell
+		Expect(old.ConvertTo(&dst)).To(Succeed())
+		Expect(dst.ObjectMeta).To(Equal(src.ObjectMeta))
+		Expect(dst.Spec).To(Equal(src.Spec))
+		Expect(dst.Status).To(Equal(src.Status))
+	})
+
+	It("round-trips a v1alpha1 Cell through v1beta1", func() {
+		src := &Cell{
+			ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "monitoring"},
+			Spec: CellSpec{
+				ClusterName:     "eu01",
+				GitpodNamespace: "gitpod",
+				Metrics: MetricsSpec{
+					UpstreamRemoteWrites: []pov1.RemoteWriteSpec{{URL: "https://a.example.com/api/v1/write"}},
+					Droplist:             []string{"go_.*"},
+					UpstreamAllowlist:    []string{"gitpod_.*"},
+				},
+			},
+			Status: CellStatus{
+				PrometheusOperatorReady: status.PrometheusOperatorReady,
+				PrometheusReady:         status.PrometheusReady,
+				Conditions:              status.Conditions,
+			},
+		}
+
+		var hub v1beta1.Cell
+		Expect(src.ConvertTo(&hub)).To(Succeed())
+		Expect(hub.Annotations).To(BeNil())
+
+		var dst Cell
+		Expect(dst.ConvertFrom(&hub)).To(Succeed())
+		Expect(dst.Spec).To(Equal(src.Spec))
+		Expect(dst.Status).To(Equal(src.Status))
+
+		var restored v1beta1.CellSpec
+		Expect(json.Unmarshal([]byte(dst.Annotations[ConversionDataAnnotation]), &restored)).To(Succeed())
+		Expect(restored).To(Equal(hub.Spec))
+	})
+
+	It("keeps the v1beta1 fields while a v1alpha1 client changes the fields it knows", func() {
+		var old Cell
+		Expect(old.ConvertFrom(hub())).To(Succeed())
+		old.Spec.ClusterName = "eu02"
+		old.Spec.Metrics.Droplist = nil
+
+		var dst v1beta1.Cell
+		Expect(old.ConvertTo(&dst)).To(Succeed())
+		Expect(dst.Spec.ClusterName).To(Equal("eu02"))
+		Expect(dst.Spec.Metrics.DropList).To(BeNil())
+		Expect(dst.Spec.Paused).To(BeTrue())
+		Expect(dst.Spec.PausedUntil).To(Equal(&pausedUntil))
+		Expect(dst.Spec.RolloutDeadline).To(Equal(&metav1.Duration{Duration: 5 * time.Minute}))
+		Expect(dst.Spec.ScrapeTLS.Mode).To(Equal(v1beta1.ScrapeTLSVerify))
+	})
+
+	It("keeps the v1beta1 status while a v1alpha1 client writes the status it knows", func() {
+		var old Cell
+		Expect(old.ConvertFrom(hub())).To(Succeed())
+		old.Status.PrometheusReady = pointer.Bool(true)
+		old.Status.Conditions[0].Status = metav1.ConditionTrue
+
+		var dst v1beta1.Cell
+		Expect(old.ConvertTo(&dst)).To(Succeed())
+		Expect(dst.Annotations).NotTo(HaveKey(StatusConversionDataAnnotation))
+		Expect(dst.Status.PrometheusReady).To(Equal(pointer.Bool(true)))
+		Expect(dst.Status.Conditions[0].Status).To(Equal(metav1.ConditionTrue))
+		Expect(dst.Status.DryRun).To(Equal(status.DryRun))
+		Expect(dst.Status.Rollouts).To(Equal(status.Rollouts))
+		Expect(dst.Status.Certificates).To(Equal(status.Certificates))
+	})
+
+	It("matches remote writes by index and URL", func() {
+		var old Cell
+		Expect(old.ConvertFrom(hub())).To(Succeed())
+		// Dropping the first endpoint shifts the second into its place, where its URL no longer matches.
+		old.Spec.Metrics.UpstreamRemoteWrites = append(old.Spec.Metrics.UpstreamRemoteWrites[1:],
+			pov1.RemoteWriteSpec{URL: "https://c.example.com/api/v1/write"})
+
+		var dst v1beta1.Cell
+		Expect(old.ConvertTo(&dst)).To(Succeed())
+		Expect(dst.Spec.Metrics.RemoteWrite).To(Equal([]v1beta1.RemoteWriteSpec{
+			{RemoteWriteSpec: pov1.RemoteWriteSpec{URL: "https://b.example.com/api/v1/write"}},
+			{RemoteWriteSpec: pov1.RemoteWriteSpec{URL: "https://c.example.com/api/v1/write"}},
+		}))
+
+		Expect(old.ConvertFrom(hub())).To(Succeed())
+		old.Spec.Metrics.UpstreamRemoteWrites[1].RemoteTimeout = "10s"
+		Expect(old.ConvertTo(&dst)).To(Succeed())
+		Expect(dst.Spec.Metrics.RemoteWrite[0].Preset).To(Equal(v1beta1.RemoteWritePresetLowLatency))
+		Expect(dst.Spec.Metrics.RemoteWrite[1].AllowList).To(Equal([]string{"up"}))
+		Expect(dst.Spec.Metrics.RemoteWrite[1].RemoteTimeout).To(BeEquivalentTo("10s"))
+	})
+
+	It("gives v1alpha1 clients an empty list of remote writes they can write back", func() {
+		src := hub()
+		src.Spec.Metrics.RemoteWrite = nil
+
+		var old Cell
+		Expect(old.ConvertFrom(src)).To(Succeed())
+		Expect(old.Spec.Metrics.UpstreamRemoteWrites).NotTo(BeNil())
+		Expect(old.Spec.Metrics.UpstreamRemoteWrites).To(BeEmpty())
+
+		var dst v1beta1.Cell
+		Expect(old.ConvertTo(&dst)).To(Succeed())
+		Expect(dst.Spec.Metrics.RemoteWrite).To(BeNil())
+	})
+})
@@ -81,10 +81,17 @@ type CellStatus struct {
 
 	// APIServerReady reports whether Prometheus is able to scrape node-exporter metrics or not
 	APIServerReady *bool `json:"apiServerReady,omitempty"`
+
+	// Conditions represent the latest available observations of the Cell's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:deprecatedversion:warning="monitoring.gitpod.io/v1alpha1 Cell is deprecated, use monitoring.gitpod.io/v1beta1"
 
 // Cell is the Schema for the cells API
 type Cell struct {
@@ -0,0 +1,636 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	pov1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// CellSpec defines the desired state of Cell
+type CellSpec struct {
+	// ClusterName will be added as extra data to all metrics, logs and traces when being sent to a remote storage
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// Gitpod defines how the Gitpod installation in the cluster is monitored
+	// +optional
+	Gitpod GitpodSpec `json:"gitpod,omitempty"`
+
+	// Provider is the Kubernetes distribution the Cell runs on. It picks the defaults that differ between them,
+	// such as the node labels kube-state-metrics exposes and the Kubernetes components that can be monitored.
+	// +kubebuilder:default=generic
+	// +optional
+	Provider Provider `json:"provider,omitempty"`
+
+	// +optional
+	Metrics MetricsSpec `json:"metrics,omitempty"`
+	// +optional
+	NodeExporter NodeExporterSpec `json:"nodeExporter,omitempty"`
+	// +optional
+	KubeStateMetrics KubeStateMetricsSpec `json:"kubeStateMetrics,omitempty"`
+	// +optional
+	Kubernetes KubernetesSpec `json:"kubernetes,omitempty"`
+	// +optional
+	ScrapeTLS ScrapeTLSSpec `json:"scrapeTLS,omitempty"`
+	// +optional
+	NetworkPolicy NetworkPolicySpec `json:"networkPolicy,omitempty"`
+	// +optional
+	Logs LogsSpec `json:"logs,omitempty"`
+	// +optional
+	Traces TracesSpec `json:"traces,omitempty"`
+
+	// Paused stops the controller from changing any of the Cell's objects, e.g. while they're patched by hand
+	// during an incident. The status is still kept up to date.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// PausedUntil resumes reconciliation of a paused Cell automatically once the given time has passed
+	// +optional
+	PausedUntil *metav1.Time `json:"pausedUntil,omitempty"`
+
+	// RolloutDeadline is how long a change to one of the Cell's workloads may take to become ready before
+	// the workload is rolled back to its last known-good spec. Defaults to 10m.
+	// +optional
+	RolloutDeadline *metav1.Duration `json:"rolloutDeadline,omitempty"`
+}
+
+// Provider is a Kubernetes distribution
+// +kubebuilder:validation:Enum=generic;gke;eks;aks;k3s
+type Provider string
+
+const (
+	// ProviderGeneric is any self-managed cluster, e.g. set up with kubeadm
+	ProviderGeneric Provider = "generic"
+	// ProviderGKE is Google Kubernetes Engine
+	ProviderGKE Provider = "gke"
+	// ProviderEKS is Amazon Elastic Kubernetes Service
+	ProviderEKS Provider = "eks"
+	// ProviderAKS is Azure Kubernetes Service
+	ProviderAKS Provider = "aks"
+	// ProviderK3s is k3s, which runs the control plane and kube-proxy embedded in its own process
+	ProviderK3s Provider = "k3s"
+)
+
+// GitpodSpec defines how the Gitpod installation in the cluster is monitored
+type GitpodSpec struct {
+	// Namespace identifies the namespace where Gitpod components were deployed to
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// MetricsSpec defines how metrics are handled within a monitoring cell
+type MetricsSpec struct {
+	// RemoteWrite defines the remote-write configuration used by the Prometheus instance. Endpoints must have
+	// unique names, or unique URLs where they have none.
+	// +optional
+	RemoteWrite []RemoteWriteSpec `json:"remoteWrite,omitempty"`
+
+	// DropList defines metrics that will be dropped during scrape time. Metrics added to DropList won't be available at any stage of our metrics pipeline
+	// +optional
+	DropList []string `json:"dropList,omitempty"`
+
+	// RemoteWriteAllowList defines which metrics are allowed to be remote-written to endpoints without an allowList of their own
+	// +optional
+	RemoteWriteAllowList []string `json:"remoteWriteAllowList,omitempty"`
+}
+
+// RemoteWriteSpec defines an endpoint Prometheus remote-writes to
+type RemoteWriteSpec struct {
+	pov1.RemoteWriteSpec `json:",inline"`
+
+	// Preset tunes the queue of the endpoint for a kind of workload. Fields set in queueConfig take
+	// precedence over the preset.
+	// +optional
+	Preset RemoteWritePreset `json:"preset,omitempty"`
+
+	// AllowList defines which metrics are sent to the endpoint, replacing spec.metrics.remoteWriteAllowList.
+	// Entries are regular expressions matched against metric names.
+	// +optional
+	AllowList []string `json:"allowList,omitempty"`
+
+	// DropList defines metrics that won't be sent to the endpoint, while they're still sent to others
+	// +optional
+	DropList []string `json:"dropList,omitempty"`
+
+	// ExternalLabels are added to all samples sent to the endpoint, on top of the cluster label
+	// +optional
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+}
+
+// RemoteWritePreset names a set of remote-write queue settings
+// +kubebuilder:validation:Enum=low-latency;high-throughput;constrained
+type RemoteWritePreset string
+
+const (
+	// RemoteWritePresetLowLatency sends small batches often, for backends alerting on fresh data
+	RemoteWritePresetLowLatency RemoteWritePreset = "low-latency"
+	// RemoteWritePresetHighThroughput buffers more and sends large batches over many shards, for large cells
+	RemoteWritePresetHighThroughput RemoteWritePreset = "high-throughput"
+	// RemoteWritePresetConstrained keeps buffers and shards small, for cells short on memory
+	RemoteWritePresetConstrained RemoteWritePreset = "constrained"
+)
+
+// NodeExporterSpec defines how node-exporter collects metrics from the nodes
+type NodeExporterSpec struct {
+	NodeExporterConfig `json:",inline"`
+
+	// Profiles run node-exporter per node pool, each as its own DaemonSet. Their node selectors must not
+	// overlap, nodes selected by more than one are reported in the NodeExporterProfilesOverlap condition.
+	// Nodes no profile selects aren't monitored. Without profiles, a single DaemonSet runs on all nodes.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Profiles []NodeExporterProfile `json:"profiles,omitempty"`
+}
+
+// NodeExporterProfile defines how node-exporter runs on a node pool. Collector settings left empty fall back
+// to those of spec.nodeExporter.
+type NodeExporterProfile struct {
+	// Name of the node pool. It's added as the nodepool label to the pool's metrics.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+
+	// NodeSelector selects the nodes of the pool
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of node-exporter on the pool. Defaults to tolerating all taints.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Resources of node-exporter on the pool. Defaults to requesting 100m CPU and 180Mi memory.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	NodeExporterConfig `json:",inline"`
+}
+
+// NodeExporterConfig defines the collectors of node-exporter
+type NodeExporterConfig struct {
+	// EnabledCollectors turns on collectors that are off by default, e.g. systemd, processes or cgroups
+	// +optional
+	EnabledCollectors []CollectorName `json:"enabledCollectors,omitempty"`
+
+	// DisabledCollectors turns off collectors, e.g. expensive ones. wifi and hwmon are off unless enabled.
+	// +optional
+	DisabledCollectors []CollectorName `json:"disabledCollectors,omitempty"`
+
+	// MountPointsExclude overrides the regular expression of mount points the filesystem collector ignores
+	// +optional
+	MountPointsExclude string `json:"mountPointsExclude,omitempty"`
+
+	// NetDeviceExclude overrides the regular expression of network devices the netdev and netclass collectors ignore
+	// +optional
+	NetDeviceExclude string `json:"netDeviceExclude,omitempty"`
+
+	// Textfile points the textfile collector at a directory of *.prom files, so scripts on the nodes can publish metrics
+	// +optional
+	Textfile *TextfileCollectorSpec `json:"textfile,omitempty"`
+}
+
+// CollectorName names a node-exporter collector
+// +kubebuilder:validation:Pattern=`^[a-z0-9_]+$`
+type CollectorName string
+
+// TextfileCollectorSpec defines where the textfile collector reads metrics from. Exactly one source has to be set.
+// +kubebuilder:validation:MinProperties=1
+// +kubebuilder:validation:MaxProperties=1
+type TextfileCollectorSpec struct {
+	// HostPath is a directory on the nodes
+	// +optional
+	HostPath string `json:"hostPath,omitempty"`
+
+	// ConfigMap names a ConfigMap in the Cell's namespace. Each of its keys ending in .prom is read as a file.
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+}
+
+// KubeStateMetricsSpec defines which objects kube-state-metrics generates metrics about
+// +kubebuilder:validation:XValidation:rule="!(has(self.metricAllowlist) && has(self.metricDenylist))",message="metricAllowlist and metricDenylist are mutually exclusive"
+type KubeStateMetricsSpec struct {
+	// Resources to generate metrics about. Defaults to those kube-state-metrics enables by default.
+	// +optional
+	Resources []KubeStateMetricsResource `json:"resources,omitempty"`
+
+	// MetricLabelsAllowlist lists the Kubernetes labels exposed in the kube_<resource>_labels metrics.
+	// Defaults to the node pool label of the provider and the region of nodes, and the component, workspaceType,
+	// owner and metaID labels of pods.
+	// +optional
+	MetricLabelsAllowlist []ResourceKeys `json:"metricLabelsAllowlist,omitempty"`
+
+	// MetricAnnotationsAllowlist lists the Kubernetes annotations exposed in the kube_<resource>_annotations metrics
+	// +optional
+	MetricAnnotationsAllowlist []ResourceKeys `json:"metricAnnotationsAllowlist,omitempty"`
+
+	// MetricAllowlist lists regular expressions of the metrics to generate, all others are left out
+	// +optional
+	MetricAllowlist []string `json:"metricAllowlist,omitempty"`
+
+	// MetricDenylist lists regular expressions of metrics to leave out
+	// +optional
+	MetricDenylist []string `json:"metricDenylist,omitempty"`
+
+	// Shards splits kube-state-metrics into a StatefulSet of this many replicas, each generating the metrics
+	// of a share of the objects. Defaults to a single replica run by a Deployment.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Shards *int32 `json:"shards,omitempty"`
+
+	// CustomResourceState generates metrics about custom resources, e.g. Gitpod's workspaces or the Cell itself.
+	// It's a kube-state-metrics custom resource state config, with kind CustomResourceStateMetrics. kube-state-metrics
+	// is granted access to each of the custom resources it lists that the API server serves. Resources of built-in
+	// API groups are never granted.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	CustomResourceState *runtime.RawExtension `json:"customResourceState,omitempty"`
+}
+
+// ResourceKeys lists label or annotation keys of a kind of resource
+type ResourceKeys struct {
+	Resource KubeStateMetricsResource `json:"resource"`
+
+	// Keys of the labels or annotations, "*" matches all
+	// +kubebuilder:validation:MinItems=1
+	Keys []string `json:"keys"`
+}
+
+// KubeStateMetricsResource names a kind of resource kube-state-metrics generates metrics about
+// +kubebuilder:validation:Enum=certificatesigningrequests;configmaps;cronjobs;daemonsets;deployments;endpoints;horizontalpodautoscalers;ingresses;jobs;leases;limitranges;mutatingwebhookconfigurations;namespaces;networkpolicies;nodes;persistentvolumeclaims;persistentvolumes;poddisruptionbudgets;pods;replicasets;replicationcontrollers;resourcequotas;secrets;services;statefulsets;storageclasses;validatingwebhookconfigurations;volumeattachments
+type KubeStateMetricsResource string
+
+// KubernetesSpec defines which Kubernetes components are monitored besides the kubelet and apiserver.
+// Components whose pods aren't found in kube-system, e.g. the control plane of managed clusters, are skipped.
+type KubernetesSpec struct {
+	// +optional
+	Scheduler ComponentMonitorSpec `json:"scheduler,omitempty"`
+	// +optional
+	ControllerManager ComponentMonitorSpec `json:"controllerManager,omitempty"`
+	// +optional
+	Etcd EtcdMonitorSpec `json:"etcd,omitempty"`
+	// +optional
+	CoreDNS ComponentMonitorSpec `json:"coreDNS,omitempty"`
+	// +optional
+	KubeProxy ComponentMonitorSpec `json:"kubeProxy,omitempty"`
+}
+
+// ComponentMonitorSpec toggles monitoring of a Kubernetes component
+type ComponentMonitorSpec struct {
+	// Enabled turns on monitoring of the component
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// EtcdMonitorSpec toggles monitoring of etcd
+type EtcdMonitorSpec struct {
+	ComponentMonitorSpec `json:",inline"`
+
+	// ClientCertSecret names a Secret in the Cell's namespace holding the client certificate Prometheus
+	// authenticates to etcd with, in the keys ca.crt, tls.crt and tls.key. etcd isn't monitored without it.
+	// +optional
+	ClientCertSecret string `json:"clientCertSecret,omitempty"`
+}
+
+// ScrapeTLSSpec defines how Prometheus checks the certificates of the HTTPS endpoints it scrapes
+type ScrapeTLSSpec struct {
+	// Mode is either Insecure, which skips verifying certificates, or Verify. Verify checks the API server
+	// and kubelets against the cluster's CA, so kubelets need serving certificates signed by it. The
+	// kube-rbac-proxy sidecars of node-exporter and kube-state-metrics are given certificates signed by a CA
+	// of the Cell's own.
+	// +kubebuilder:default=Insecure
+	// +optional
+	Mode ScrapeTLSMode `json:"mode,omitempty"`
+}
+
+// ScrapeTLSMode picks whether scrapes verify certificates
+// +kubebuilder:validation:Enum=Insecure;Verify
+type ScrapeTLSMode string
+
+const (
+	// ScrapeTLSInsecure skips verifying the certificates of scraped endpoints
+	ScrapeTLSInsecure ScrapeTLSMode = "Insecure"
+	// ScrapeTLSVerify verifies the certificates of scraped endpoints
+	ScrapeTLSVerify ScrapeTLSMode = "Verify"
+)
+
+// NetworkPolicySpec defines the NetworkPolicies restricting the traffic of the Cell, and of the Gitpod
+// components it scrapes
+type NetworkPolicySpec struct {
+	// Mode is either Permissive or Strict. Permissive lets Prometheus reach the Gitpod components on any port.
+	// Strict only lets it reach their metrics ports, and denies all traffic in the Cell's namespace but scrapes,
+	// DNS, the API server and remote-write.
+	// +kubebuilder:default=Permissive
+	// +optional
+	Mode NetworkPolicyMode `json:"mode,omitempty"`
+
+	// PrometheusUIFrom are the peers allowed to reach the Prometheus UI in Strict mode. Everyone is allowed if
+	// it's empty. The controller queries Prometheus through the API server, so the API server has to be allowed.
+	// +optional
+	PrometheusUIFrom []networkingv1.NetworkPolicyPeer `json:"prometheusUIFrom,omitempty"`
+}
+
+// NetworkPolicyMode picks how strictly the Cell's traffic is restricted
+// +kubebuilder:validation:Enum=Permissive;Strict
+type NetworkPolicyMode string
+
+const (
+	// NetworkPolicyPermissive lets Prometheus reach the Gitpod components on any port
+	NetworkPolicyPermissive NetworkPolicyMode = "Permissive"
+	// NetworkPolicyStrict restricts all traffic of the Cell to what it needs
+	NetworkPolicyStrict NetworkPolicyMode = "Strict"
+)
+
+// LogsSpec defines how logs are handled within a monitoring cell
+type LogsSpec struct {
+}
+
+// TracesSpec defines how traces are handled within a monitoring cell
+type TracesSpec struct {
+}
+
+// CellStatus defines the observed state of Cell
+type CellStatus struct {
+	// PrometheusOperatorReady reports whether Prometheus-Operator is in a ready or broken state
+	PrometheusOperatorReady *bool `json:"prometheusOperatorReady,omitempty"`
+
+	// PrometheusReady reports whether Prometheus is in a ready or broken state
+	PrometheusReady *bool `json:"prometheusReady,omitempty"`
+
+	// NodeExporterReady reports whether Prometheus is able to scrape node-exporter metrics or not
+	NodeExporterReady *bool `json:"nodeExporterReady,omitempty"`
+
+	// KubeStateMetricsReady reports whether Prometheus is able to scrape kube-state-metrics metrics or not
+	KubeStateMetricsReady *bool `json:"kubeStateMetricsReady,omitempty"`
+
+	// KubeletReady reports whether Prometheus is able to scrape kubelet metrics or not
+	KubeletReady *bool `json:"kubeletReady,omitempty"`
+
+	// APIServerReady reports whether Prometheus is able to scrape apiserver metrics or not
+	APIServerReady *bool `json:"apiServerReady,omitempty"`
+
+	// Conditions represent the latest available observations of the Cell's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// DryRun reports the changes the controller would make to the Cell's objects. It's only set while
+	// the Cell is reconciled in dry-run mode.
+	// +optional
+	DryRun *DryRunStatus `json:"dryRun,omitempty"`
+
+	// Rollouts tracks the rollout of the latest change to each of the Cell's workloads
+	// +optional
+	// +listType=map
+	// +listMapKey=kind
+	// +listMapKey=name
+	Rollouts []WorkloadRollout `json:"rollouts,omitempty"`
+
+	// RemoteWrite reports on each of the endpoints in spec.metrics.remoteWrite
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	RemoteWrite []RemoteWriteStatus `json:"remoteWrite,omitempty"`
+
+	// KubernetesComponents reports on the Kubernetes components enabled in spec.kubernetes
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	KubernetesComponents []KubernetesComponentStatus `json:"kubernetesComponents,omitempty"`
+
+	// CustomResources reports on the resources listed in spec.kubeStateMetrics.customResourceState
+	// +optional
+	CustomResources []CustomResourceStatus `json:"customResources,omitempty"`
+	// Certificates reports on the Cell's CA and the serving certificates issued with it, while scrapes verify TLS
+	// Certificates reports on the Cell's CA and the serving certificates issued with it
+	// +optional
+	// +listType=map
+	// +listMapKey=secretName
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+
+	// CredentialsHash identifies the revisions of the Secrets referenced in spec.metrics.remoteWrite.
+	// Prometheus is restarted whenever it changes.
+	// +optional
+	CredentialsHash string `json:"credentialsHash,omitempty"`
+}
+
+// RemoteWriteStatus reports on an endpoint Prometheus remote-writes to
+type RemoteWriteStatus struct {
+	// Name of the endpoint, or its URL if it has none
+	Name string `json:"name"`
+
+	URL string `json:"url,omitempty"`
+
+	// CredentialsPresent is true when all Secret keys the endpoint references for its credentials exist.
+	// The credentials themselves are never reported.
+	// +optional
+	CredentialsPresent *bool `json:"credentialsPresent,omitempty"`
+
+	// CredentialsMessage lists the Secret keys that are missing
+	// +optional
+	CredentialsMessage string `json:"credentialsMessage,omitempty"`
+
+	// Healthy is true while Prometheus sends samples to the endpoint without failures and keeps up with
+	// ingestion. It's unset until Prometheus reports metrics for the endpoint.
+	// +optional
+	Healthy *bool `json:"healthy,omitempty"`
+
+	// HealthMessage explains why the endpoint isn't healthy
+	// +optional
+	HealthMessage string `json:"healthMessage,omitempty"`
+
+	// SamplesFailedRate is the per-second rate of samples that failed to be sent over the last 5 minutes
+	// +optional
+	SamplesFailedRate string `json:"samplesFailedRate,omitempty"`
+
+	// SamplesDroppedRate is the per-second rate of samples dropped before being sent over the last 5 minutes,
+	// including those dropped on purpose by relabelling
+	// +optional
+	SamplesDroppedRate string `json:"samplesDroppedRate,omitempty"`
+
+	// LagSeconds is how far the newest sample sent lags behind the newest sample ingested
+	// +optional
+	LagSeconds *int64 `json:"lagSeconds,omitempty"`
+
+	// Shards is the number of shards sending samples in parallel
+	// +optional
+	Shards *int32 `json:"shards,omitempty"`
+
+	// DesiredShards is the number of shards Prometheus calculated it needs to keep up
+	// +optional
+	DesiredShards *int32 `json:"desiredShards,omitempty"`
+}
+
+// KubernetesComponentStatus reports whether a Kubernetes component is monitored
+type KubernetesComponentStatus struct {
+	Name string `json:"name"`
+
+	// Monitored is true when the component's pods were found and a monitor is rendered for it
+	Monitored bool `json:"monitored"`
+
+	// Message explains why the component isn't monitored
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CustomResourceStatus reports whether kube-state-metrics is granted access to a custom resource
+type CustomResourceStatus struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+
+	// Resource is the plural the API server serves the kind as
+	// +optional
+	Resource string `json:"resource,omitempty"`
+
+	// Granted is true when kube-state-metrics may list and watch the resource
+	Granted bool `json:"granted"`
+
+	// Message explains why access isn't granted
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CertificateStatus reports on a certificate the controller issued
+type CertificateStatus struct {
+	// SecretName is the Secret the certificate is kept in
+	SecretName string `json:"secretName"`
+
+	// DNSNames are the names a serving certificate is valid for. The CA has none.
+	// +optional
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// NotAfter is when the certificate expires
+	NotAfter metav1.Time `json:"notAfter"`
+
+	// RenewAfter is when the certificate is replaced
+	RenewAfter metav1.Time `json:"renewAfter"`
+}
+
+// DryRunStatus summarises the outcome of a server-side dry-run of all the Cell's objects
+type DryRunStatus struct {
+	// Time is when the dry-run last found a different set of changes
+	Time metav1.Time `json:"time"`
+
+	// Changes lists the objects that would be created, updated or deleted. Objects that are up to date are left out.
+	// +optional
+	Changes []ObjectChange `json:"changes,omitempty"`
+}
+
+// ObjectChange describes how a single object would change if the Cell was reconciled
+type ObjectChange struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Action is either Create, Update or Delete
+	Action string `json:"action"`
+
+	// Fields lists the paths of the fields that differ from the live object
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+}
+
+// RolloutPhase is the state of the rollout of a workload
+type RolloutPhase string
+
+const (
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	RolloutPhaseComplete    RolloutPhase = "Complete"
+	RolloutPhaseFailed      RolloutPhase = "Failed"
+)
+
+// WorkloadRollout tracks the rollout of a Deployment, DaemonSet, StatefulSet or Prometheus of the Cell
+type WorkloadRollout struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	// SpecHash identifies the desired spec that is being, or was, rolled out
+	SpecHash string `json:"specHash"`
+
+	Phase RolloutPhase `json:"phase"`
+
+	// StartTime is when the rollout of the desired spec started
+	StartTime metav1.Time `json:"startTime"`
+
+	// LastKnownGood is the spec of the workload after its last successful rollout. A failed rollout is
+	// reverted to it.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	LastKnownGood *runtime.RawExtension `json:"lastKnownGood,omitempty"`
+}
+
+const (
+	// Ready is true when all components of the Cell are up and scraped, and the controller is reconciling them
+	Ready = "Ready"
+
+	// CRDsMissing is true when the prometheus-operator CRDs the Cell depends on aren't installed in the cluster
+	CRDsMissing = "CRDsMissing"
+
+	// RolloutFailed is true when a change to one of the Cell's workloads didn't become ready within the rollout deadline
+	RolloutFailed = "RolloutFailed"
+
+	// CredentialsMissing is true when a Secret key referenced for remote-write credentials doesn't exist
+	CredentialsMissing = "CredentialsMissing"
+
+	// RemoteWriteHealthy is true when Prometheus sends samples to all remote-write endpoints without failures
+	RemoteWriteHealthy = "RemoteWriteHealthy"
+
+	// NodeExporterProfilesOverlap is true when a node is selected by more than one node-exporter profile. Only
+	// one of their pods can bind the node-exporter host port, the others never get ready.
+	NodeExporterProfilesOverlap = "NodeExporterProfilesOverlap"
+)
+
+const (
+	// DryRunAnnotation set to "true" on a Cell makes the controller report what it would change instead of applying it
+	DryRunAnnotation = "monitoring.gitpod.io/dry-run"
+
+	// CredentialsLabel set to "true" on a Secret referenced for remote-write credentials gets Prometheus
+	// restarted as soon as the Secret changes. Changes to other Secrets are picked up on the next resync.
+	CredentialsLabel = "monitoring.gitpod.io/credentials"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Cell is the Schema for the cells API
+type Cell struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CellSpec   `json:"spec,omitempty"`
+	Status CellStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CellList contains a list of Cell
+type CellList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Cell `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Cell{}, &CellList{})
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// SetupWebhookWithManager registers the conversion and validating webhooks of the Cell API with the manager's
+// webhook server.
+func (r *Cell) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-monitoring-gitpod-io-v1beta1-cell,mutating=false,failurePolicy=fail,sideEffects=None,groups=monitoring.gitpod.io,resources=cells,verbs=create;update,versions=v1beta1,name=vcell.monitoring.gitpod.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Cell{}
+
+// ValidateCreate implements webhook.Validator
+func (r *Cell) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator
+func (r *Cell) ValidateUpdate(_ runtime.Object) error {
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator
+func (r *Cell) ValidateDelete() error {
+	return nil
+}
+
+// validate checks what the schema can't express. Remote-write endpoints are reported in the status by their
+// name, or their URL if they have none, so these have to be unique.
+func (r *Cell) validate() error {
+	var errs field.ErrorList
+
+	path := field.NewPath("spec", "metrics", "remoteWrite")
+	seen := map[string]bool{}
+	for i, rw := range r.Spec.Metrics.RemoteWrite {
+		key, keyPath := rw.Name, path.Index(i).Child("name")
+		if key == "" {
+			key, keyPath = rw.URL, path.Index(i).Child("url")
+		}
+		if seen[key] {
+			errs = append(errs, field.Duplicate(keyPath, key))
+		}
+		seen[key] = true
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Cell").GroupKind(), r.Name, errs)
+}
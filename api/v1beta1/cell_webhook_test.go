@@ -0,0 +1,45 @@
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	pov1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var _ = Describe("Cell validation", func() {
+	cell := func(remoteWrites ...pov1.RemoteWriteSpec) *Cell {
+		c := &Cell{}
+		for _, rw := range remoteWrites {
+			c.Spec.Metrics.RemoteWrite = append(c.Spec.Metrics.RemoteWrite, RemoteWriteSpec{RemoteWriteSpec: rw})
+		}
+		return c
+	}
+
+	It("accepts remote writes with unique names, or unique URLs where they have none", func() {
+		Expect(cell(
+			pov1.RemoteWriteSpec{Name: "tenant-a", URL: "https://metrics.example.com"},
+			pov1.RemoteWriteSpec{Name: "tenant-b", URL: "https://metrics.example.com"},
+			pov1.RemoteWriteSpec{URL: "https://other.example.com"},
+		).ValidateCreate()).To(Succeed())
+	})
+
+	It("rejects remote writes sharing a name", func() {
+		err := cell(
+			pov1.RemoteWriteSpec{Name: "upstream", URL: "https://a.example.com"},
+			pov1.RemoteWriteSpec{Name: "upstream", URL: "https://b.example.com"},
+		).ValidateUpdate(&Cell{})
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.metrics.remoteWrite[1].name"))
+	})
+
+	It("rejects unnamed remote writes sharing a URL", func() {
+		err := cell(
+			pov1.RemoteWriteSpec{URL: "https://a.example.com"},
+			pov1.RemoteWriteSpec{URL: "https://a.example.com"},
+		).ValidateCreate()
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.metrics.remoteWrite[1].url"))
+	})
+})
@@ -0,0 +1,745 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Cell) DeepCopyInto(out *Cell) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cell.
+func (in *Cell) DeepCopy() *Cell {
+	if in == nil {
+		return nil
+	}
+	out := new(Cell)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Cell) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellList) DeepCopyInto(out *CellList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Cell, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellList.
+func (in *CellList) DeepCopy() *CellList {
+	if in == nil {
+		return nil
+	}
+	out := new(CellList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CellList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellSpec) DeepCopyInto(out *CellSpec) {
+	*out = *in
+	out.Gitpod = in.Gitpod
+	in.Metrics.DeepCopyInto(&out.Metrics)
+	in.NodeExporter.DeepCopyInto(&out.NodeExporter)
+	in.KubeStateMetrics.DeepCopyInto(&out.KubeStateMetrics)
+	in.Kubernetes.DeepCopyInto(&out.Kubernetes)
+	out.ScrapeTLS = in.ScrapeTLS
+	in.NetworkPolicy.DeepCopyInto(&out.NetworkPolicy)
+	out.Logs = in.Logs
+	out.Traces = in.Traces
+	if in.PausedUntil != nil {
+		in, out := &in.PausedUntil, &out.PausedUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.RolloutDeadline != nil {
+		in, out := &in.RolloutDeadline, &out.RolloutDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellSpec.
+func (in *CellSpec) DeepCopy() *CellSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CellSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CellStatus) DeepCopyInto(out *CellStatus) {
+	*out = *in
+	if in.PrometheusOperatorReady != nil {
+		in, out := &in.PrometheusOperatorReady, &out.PrometheusOperatorReady
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PrometheusReady != nil {
+		in, out := &in.PrometheusReady, &out.PrometheusReady
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NodeExporterReady != nil {
+		in, out := &in.NodeExporterReady, &out.NodeExporterReady
+		*out = new(bool)
+		**out = **in
+	}
+	if in.KubeStateMetricsReady != nil {
+		in, out := &in.KubeStateMetricsReady, &out.KubeStateMetricsReady
+		*out = new(bool)
+		**out = **in
+	}
+	if in.KubeletReady != nil {
+		in, out := &in.KubeletReady, &out.KubeletReady
+		*out = new(bool)
+		**out = **in
+	}
+	if in.APIServerReady != nil {
+		in, out := &in.APIServerReady, &out.APIServerReady
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(DryRunStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollouts != nil {
+		in, out := &in.Rollouts, &out.Rollouts
+		*out = make([]WorkloadRollout, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RemoteWrite != nil {
+		in, out := &in.RemoteWrite, &out.RemoteWrite
+		*out = make([]RemoteWriteStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.KubernetesComponents != nil {
+		in, out := &in.KubernetesComponents, &out.KubernetesComponents
+		*out = make([]KubernetesComponentStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomResources != nil {
+		in, out := &in.CustomResources, &out.CustomResources
+		*out = make([]CustomResourceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]CertificateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CellStatus.
+func (in *CellStatus) DeepCopy() *CellStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CellStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateStatus) DeepCopyInto(out *CertificateStatus) {
+	*out = *in
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
+	in.RenewAfter.DeepCopyInto(&out.RenewAfter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateStatus.
+func (in *CertificateStatus) DeepCopy() *CertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentMonitorSpec) DeepCopyInto(out *ComponentMonitorSpec) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentMonitorSpec.
+func (in *ComponentMonitorSpec) DeepCopy() *ComponentMonitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentMonitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceStatus) DeepCopyInto(out *CustomResourceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceStatus.
+func (in *CustomResourceStatus) DeepCopy() *CustomResourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunStatus) DeepCopyInto(out *DryRunStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]ObjectChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunStatus.
+func (in *DryRunStatus) DeepCopy() *DryRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdMonitorSpec) DeepCopyInto(out *EtcdMonitorSpec) {
+	*out = *in
+	in.ComponentMonitorSpec.DeepCopyInto(&out.ComponentMonitorSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdMonitorSpec.
+func (in *EtcdMonitorSpec) DeepCopy() *EtcdMonitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdMonitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitpodSpec) DeepCopyInto(out *GitpodSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitpodSpec.
+func (in *GitpodSpec) DeepCopy() *GitpodSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitpodSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeStateMetricsSpec) DeepCopyInto(out *KubeStateMetricsSpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]KubeStateMetricsResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.MetricLabelsAllowlist != nil {
+		in, out := &in.MetricLabelsAllowlist, &out.MetricLabelsAllowlist
+		*out = make([]ResourceKeys, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricAnnotationsAllowlist != nil {
+		in, out := &in.MetricAnnotationsAllowlist, &out.MetricAnnotationsAllowlist
+		*out = make([]ResourceKeys, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricAllowlist != nil {
+		in, out := &in.MetricAllowlist, &out.MetricAllowlist
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MetricDenylist != nil {
+		in, out := &in.MetricDenylist, &out.MetricDenylist
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CustomResourceState != nil {
+		in, out := &in.CustomResourceState, &out.CustomResourceState
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeStateMetricsSpec.
+func (in *KubeStateMetricsSpec) DeepCopy() *KubeStateMetricsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeStateMetricsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubernetesComponentStatus) DeepCopyInto(out *KubernetesComponentStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubernetesComponentStatus.
+func (in *KubernetesComponentStatus) DeepCopy() *KubernetesComponentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KubernetesComponentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubernetesSpec) DeepCopyInto(out *KubernetesSpec) {
+	*out = *in
+	in.Scheduler.DeepCopyInto(&out.Scheduler)
+	in.ControllerManager.DeepCopyInto(&out.ControllerManager)
+	in.Etcd.DeepCopyInto(&out.Etcd)
+	in.CoreDNS.DeepCopyInto(&out.CoreDNS)
+	in.KubeProxy.DeepCopyInto(&out.KubeProxy)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubernetesSpec.
+func (in *KubernetesSpec) DeepCopy() *KubernetesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubernetesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogsSpec) DeepCopyInto(out *LogsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogsSpec.
+func (in *LogsSpec) DeepCopy() *LogsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LogsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsSpec) DeepCopyInto(out *MetricsSpec) {
+	*out = *in
+	if in.RemoteWrite != nil {
+		in, out := &in.RemoteWrite, &out.RemoteWrite
+		*out = make([]RemoteWriteSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DropList != nil {
+		in, out := &in.DropList, &out.DropList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemoteWriteAllowList != nil {
+		in, out := &in.RemoteWriteAllowList, &out.RemoteWriteAllowList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsSpec.
+func (in *MetricsSpec) DeepCopy() *MetricsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
+	*out = *in
+	if in.PrometheusUIFrom != nil {
+		in, out := &in.PrometheusUIFrom, &out.PrometheusUIFrom
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicySpec.
+func (in *NetworkPolicySpec) DeepCopy() *NetworkPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeExporterConfig) DeepCopyInto(out *NodeExporterConfig) {
+	*out = *in
+	if in.EnabledCollectors != nil {
+		in, out := &in.EnabledCollectors, &out.EnabledCollectors
+		*out = make([]CollectorName, len(*in))
+		copy(*out, *in)
+	}
+	if in.DisabledCollectors != nil {
+		in, out := &in.DisabledCollectors, &out.DisabledCollectors
+		*out = make([]CollectorName, len(*in))
+		copy(*out, *in)
+	}
+	if in.Textfile != nil {
+		in, out := &in.Textfile, &out.Textfile
+		*out = new(TextfileCollectorSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeExporterConfig.
+func (in *NodeExporterConfig) DeepCopy() *NodeExporterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeExporterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeExporterProfile) DeepCopyInto(out *NodeExporterProfile) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(corev1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	in.NodeExporterConfig.DeepCopyInto(&out.NodeExporterConfig)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeExporterProfile.
+func (in *NodeExporterProfile) DeepCopy() *NodeExporterProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeExporterProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeExporterSpec) DeepCopyInto(out *NodeExporterSpec) {
+	*out = *in
+	in.NodeExporterConfig.DeepCopyInto(&out.NodeExporterConfig)
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make([]NodeExporterProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeExporterSpec.
+func (in *NodeExporterSpec) DeepCopy() *NodeExporterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeExporterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectChange) DeepCopyInto(out *ObjectChange) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectChange.
+func (in *ObjectChange) DeepCopy() *ObjectChange {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteWriteSpec) DeepCopyInto(out *RemoteWriteSpec) {
+	*out = *in
+	in.RemoteWriteSpec.DeepCopyInto(&out.RemoteWriteSpec)
+	if in.AllowList != nil {
+		in, out := &in.AllowList, &out.AllowList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DropList != nil {
+		in, out := &in.DropList, &out.DropList
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExternalLabels != nil {
+		in, out := &in.ExternalLabels, &out.ExternalLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteWriteSpec.
+func (in *RemoteWriteSpec) DeepCopy() *RemoteWriteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteWriteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteWriteStatus) DeepCopyInto(out *RemoteWriteStatus) {
+	*out = *in
+	if in.CredentialsPresent != nil {
+		in, out := &in.CredentialsPresent, &out.CredentialsPresent
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Healthy != nil {
+		in, out := &in.Healthy, &out.Healthy
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LagSeconds != nil {
+		in, out := &in.LagSeconds, &out.LagSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DesiredShards != nil {
+		in, out := &in.DesiredShards, &out.DesiredShards
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteWriteStatus.
+func (in *RemoteWriteStatus) DeepCopy() *RemoteWriteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteWriteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceKeys) DeepCopyInto(out *ResourceKeys) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceKeys.
+func (in *ResourceKeys) DeepCopy() *ResourceKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceKeys)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScrapeTLSSpec) DeepCopyInto(out *ScrapeTLSSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScrapeTLSSpec.
+func (in *ScrapeTLSSpec) DeepCopy() *ScrapeTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScrapeTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TextfileCollectorSpec) DeepCopyInto(out *TextfileCollectorSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TextfileCollectorSpec.
+func (in *TextfileCollectorSpec) DeepCopy() *TextfileCollectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TextfileCollectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TracesSpec) DeepCopyInto(out *TracesSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TracesSpec.
+func (in *TracesSpec) DeepCopy() *TracesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TracesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadRollout) DeepCopyInto(out *WorkloadRollout) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.LastKnownGood != nil {
+		in, out := &in.LastKnownGood, &out.LastKnownGood
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadRollout.
+func (in *WorkloadRollout) DeepCopy() *WorkloadRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadRollout)
+	in.DeepCopyInto(out)
+	return out
+}
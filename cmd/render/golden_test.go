@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var update = flag.Bool("update", false, "Write the rendered objects to the golden files instead of comparing them.")
+
+var _ = Describe("Golden output", func() {
+	DescribeTable("renders the objects of a Cell",
+		func(manifest, golden string) {
+			cell, err := readCell(manifest)
+			Expect(err).NotTo(HaveOccurred())
+			if cell.Namespace == "" {
+				cell.Namespace = "default"
+			}
+			var out bytes.Buffer
+			Expect(render(cell, &out, &bytes.Buffer{})).To(Succeed())
+
+			golden = filepath.Join("testdata", golden)
+			if *update {
+				Expect(os.WriteFile(golden, out.Bytes(), 0o644)).To(Succeed())
+			}
+			expected, err := os.ReadFile(golden)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(Equal(string(expected)), "rendered objects changed, run `go test ./cmd/render -args -update` if that's intended")
+		},
+		Entry("the sample", filepath.Join("..", "..", "config", "samples", "monitoring_v1beta1_cell.yaml"), "sample.golden.yaml"),
+		Entry("the v1alpha1 sample, the same as the sample", filepath.Join("..", "..", "config", "samples", "monitoring_v1alpha1_cell.yaml"), "sample.golden.yaml"),
+		Entry("a Cell on GKE with Kubernetes components, remote writes, profiles and custom resources", filepath.Join("testdata", "cell.yaml"), "cell.golden.yaml"),
+	)
+})
@@ -0,0 +1,180 @@
+// render prints every object the operator would create for a Cell as multi-document YAML,
+// without talking to a cluster.
+//
+//	go run ./cmd/render -f config/samples/monitoring_v1beta1_cell.yaml
+//
+// Cells in the deprecated v1alpha1 version are converted to v1beta1 first.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/kubernetes"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+)
+
+func main() {
+	var cellFile string
+	var namespace string
+	flag.StringVar(&cellFile, "f", "-", "Path to the Cell manifest to render. Use - to read from stdin.")
+	flag.StringVar(&namespace, "namespace", "default", "Namespace to render into when the Cell doesn't set one.")
+	flag.Parse()
+
+	cell, err := readCell(cellFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to read cell: %v\n", err)
+		os.Exit(1)
+	}
+	if cell.Namespace == "" {
+		cell.Namespace = namespace
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if err := render(cell, w, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to render cell: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// render writes the objects of the cell to w. What the operator would find out from the cluster is assumed
+// instead, and each assumption is written to warnings.
+func render(cell *monitoringv1beta1.Cell, w, warnings io.Writer) error {
+	// The CA and serving certificates are generated by the operator and never rendered, as their private keys
+	// don't belong in manifests. Without them the Verify mode would render pods mounting Secrets that don't exist.
+	if cell.Spec.ScrapeTLS.Mode == monitoringv1beta1.ScrapeTLSVerify {
+		return fmt.Errorf("scrapeTLS mode %s needs the certificates issued by the operator, render with mode %s instead",
+			monitoringv1beta1.ScrapeTLSVerify, monitoringv1beta1.ScrapeTLSInsecure)
+	}
+	assumeKubernetesComponents(cell, warnings)
+	assumeCustomResources(cell, warnings)
+
+	for _, obj := range components.Objects(cell) {
+		// A Cell read from a file has no UID, and owner references without one are rejected by the API server.
+		// The rendered objects are meant to be applied on their own, so leave them unowned.
+		if cell.UID == "" {
+			obj.SetOwnerReferences(nil)
+		}
+
+		b, err := toYAML(obj)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+		}
+		fmt.Fprintf(w, "---\n%s", b)
+	}
+
+	return nil
+}
+
+// assumeKubernetesComponents fills in the status the operator discovers the enabled Kubernetes components with.
+// Their pods can't be looked for, so the components are assumed to run unless they can't be monitored anyway.
+func assumeKubernetesComponents(cell *monitoringv1beta1.Cell, warnings io.Writer) {
+	cell.Status.KubernetesComponents = nil
+	for _, component := range kubernetes.EnabledComponents(cell) {
+		status := monitoringv1beta1.KubernetesComponentStatus{Name: component.Name, Message: kubernetes.Unavailable(cell, component)}
+		if status.Message == "" {
+			status.Monitored = true
+			fmt.Fprintf(warnings, "warning: assuming %s runs in %s, the operator would skip it if it found no pods\n", component.Name, kubernetes.Namespace)
+		} else {
+			fmt.Fprintf(warnings, "warning: not monitoring %s: %s\n", component.Name, status.Message)
+		}
+		cell.Status.KubernetesComponents = append(cell.Status.KubernetesComponents, status)
+	}
+}
+
+func readCell(path string) (*monitoringv1beta1.Cell, error) {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCell(b)
+}
+
+// parseCell reads a Cell manifest of either version.
+func parseCell(b []byte) (*monitoringv1beta1.Cell, error) {
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal(b, &typeMeta); err != nil {
+		return nil, err
+	}
+
+	var cell monitoringv1beta1.Cell
+	switch typeMeta.GroupVersionKind() {
+	case monitoringv1beta1.GroupVersion.WithKind("Cell"):
+		if err := yaml.Unmarshal(b, &cell); err != nil {
+			return nil, err
+		}
+	case monitoringv1alpha1.GroupVersion.WithKind("Cell"):
+		var old monitoringv1alpha1.Cell
+		if err := yaml.Unmarshal(b, &old); err != nil {
+			return nil, err
+		}
+		if err := old.ConvertTo(&cell); err != nil {
+			return nil, err
+		}
+		cell.SetGroupVersionKind(monitoringv1beta1.GroupVersion.WithKind("Cell"))
+	default:
+		return nil, fmt.Errorf("expected a %s Cell, got %q", monitoringv1beta1.GroupVersion, typeMeta.GroupVersionKind())
+	}
+	if cell.Labels == nil {
+		cell.Labels = map[string]string{}
+	}
+
+	return &cell, nil
+}
+
+// assumeCustomResources fills in the status the operator resolves the custom resources of the kube-state-metrics
+// config with. Without an API server to ask, custom resources are assumed to be served under the plural
+// kube-state-metrics derives from their kind, unless the config gives one.
+func assumeCustomResources(cell *monitoringv1beta1.Cell, warnings io.Writer) {
+	cell.Status.CustomResources = nil
+	for _, resource := range kubestatemetrics.CustomResources(cell) {
+		status := monitoringv1beta1.CustomResourceStatus{Group: resource.Group, Kind: resource.Kind}
+		if kubestatemetrics.BuiltinGroup(resource.Group) {
+			status.Message = "resources of built-in API groups can't be granted"
+			fmt.Fprintf(warnings, "warning: not granting kube-state-metrics access to %s: %s\n", resource.Kind, status.Message)
+			cell.Status.CustomResources = append(cell.Status.CustomResources, status)
+			continue
+		}
+
+		status.Resource = resource.ResourcePlural
+		if status.Resource == "" {
+			status.Resource = strings.ToLower(resource.Kind) + "s"
+		}
+		status.Granted = true
+		fmt.Fprintf(warnings, "warning: assuming %s is served as %s.%s, set resourcePlural if it isn't\n", resource.Kind, status.Resource, resource.Group)
+		cell.Status.CustomResources = append(cell.Status.CustomResources, status)
+	}
+}
+
+// toYAML drops the fields the API server fills in, so the output can be committed and applied as is.
+func toYAML(obj client.Object) ([]byte, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u, "status")
+
+	return yaml.Marshal(u)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// object is the part of a rendered object the specs look at
+type object struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+	Data  map[string]string   `json:"data,omitempty"`
+}
+
+func renderCell(manifest string) ([]object, string) {
+	docs, warnings := renderDocs(manifest)
+
+	var objects []object
+	for _, doc := range docs {
+		var obj object
+		Expect(yaml.Unmarshal([]byte(doc), &obj)).To(Succeed())
+		objects = append(objects, obj)
+	}
+	return objects, warnings
+}
+
+// renderDocs renders the cell of the manifest into a YAML document per object
+func renderDocs(manifest string) ([]string, string) {
+	cell, err := parseCell([]byte(manifest))
+	Expect(err).NotTo(HaveOccurred())
+	var out, warnings bytes.Buffer
+	Expect(render(cell, &out, &warnings)).To(Succeed())
+
+	return strings.Split(out.String(), "---\n")[1:], warnings.String()
+}
+
+func named(kind, namespace, name string) object {
+	var obj object
+	obj.Kind = kind
+	obj.Metadata.Namespace = namespace
+	obj.Metadata.Name = name
+	return obj
+}
+
+var _ = Describe("render", func() {
+	It("renders the monitors of enabled Kubernetes components, assuming they run", func() {
+		objects, warnings := renderCell(`
+apiVersion: monitoring.gitpod.io/v1beta1
+kind: Cell
+metadata:
+  name: cell
+  namespace: monitoring
+spec:
+  kubernetes:
+    scheduler:
+      enabled: true
+    coreDNS:
+      enabled: true
+    etcd:
+      enabled: true
+`)
+		Expect(objects).To(ContainElements(
+			named("Service", "kube-system", "kube-scheduler-monitoring-cell"),
+			named("Service", "kube-system", "coredns-monitoring-cell"),
+			named("ServiceMonitor", "monitoring", "kube-scheduler-cell"),
+			named("ServiceMonitor", "monitoring", "coredns-cell"),
+		))
+		Expect(warnings).To(ContainSubstring("assuming kube-scheduler runs in kube-system"))
+		Expect(warnings).To(ContainSubstring("assuming coredns runs in kube-system"))
+
+		By("leaving out etcd, which can't be monitored without a client certificate")
+		Expect(objects).NotTo(ContainElement(named("ServiceMonitor", "monitoring", "etcd-cell")))
+		Expect(warnings).To(ContainSubstring("not monitoring etcd: no client certificate Secret is configured"))
+	})
+
+	It("leaves out the components the provider makes unavailable", func() {
+		objects, warnings := renderCell(`
+apiVersion: monitoring.gitpod.io/v1beta1
+kind: Cell
+metadata:
+  name: cell
+  namespace: monitoring
+spec:
+  provider: gke
+  kubernetes:
+    scheduler:
+      enabled: true
+`)
+		Expect(objects).NotTo(ContainElement(named("ServiceMonitor", "monitoring", "kube-scheduler-cell")))
+		Expect(warnings).To(ContainSubstring("not monitoring kube-scheduler: the control plane is managed by the provider"))
+	})
+
+	It("grants kube-state-metrics access to the custom resources of its config", func() {
+		objects, warnings := renderCell(`
+apiVersion: monitoring.gitpod.io/v1beta1
+kind: Cell
+metadata:
+  name: cell
+  namespace: monitoring
+spec:
+  kubeStateMetrics:
+    customResourceState:
+      kind: CustomResourceStateMetrics
+      spec:
+        resources:
+        - groupVersionKind: {group: workspace.gitpod.io, version: v1, kind: Workspace}
+        - groupVersionKind: {group: policy.example.com, version: v1, kind: Policy}
+          resourcePlural: policies
+        - groupVersionKind: {group: "", version: v1, kind: Secret}
+`)
+		var clusterRole, configMap object
+		for _, obj := range objects {
+			switch {
+			case obj.Kind == "ClusterRole" && obj.Metadata.Name == "kube-state-metrics-cell":
+				clusterRole = obj
+			case obj.Kind == "ConfigMap" && obj.Metadata.Name == "kube-state-metrics-cell":
+				configMap = obj
+			}
+		}
+		Expect(clusterRole.Rules).To(ContainElements(
+			rbacv1.PolicyRule{APIGroups: []string{"workspace.gitpod.io"}, Resources: []string{"workspaces"}, Verbs: []string{"list", "watch"}},
+			rbacv1.PolicyRule{APIGroups: []string{"policy.example.com"}, Resources: []string{"policies"}, Verbs: []string{"list", "watch"}},
+		))
+		Expect(configMap.Data["custom-resource-state.yaml"]).To(ContainSubstring(`"resourcePlural":"workspaces"`))
+		Expect(warnings).To(ContainSubstring("assuming Workspace is served as workspaces.workspace.gitpod.io"))
+		Expect(warnings).To(ContainSubstring("not granting kube-state-metrics access to Secret"))
+	})
+
+	It("refuses to render the Verify scrape TLS mode", func() {
+		cell, err := parseCell([]byte(`
+apiVersion: monitoring.gitpod.io/v1beta1
+kind: Cell
+metadata:
+  name: cell
+spec:
+  scrapeTLS:
+    mode: Verify
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(render(cell, &bytes.Buffer{}, &bytes.Buffer{})).To(MatchError(ContainSubstring("render with mode Insecure instead")))
+	})
+})
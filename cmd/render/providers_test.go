@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// providerCell enables the monitoring of every Kubernetes component, so the ones a provider leaves out show.
+const providerCell = `
+apiVersion: monitoring.gitpod.io/v1beta1
+kind: Cell
+metadata:
+  name: cell
+  namespace: monitoring
+spec:
+  provider: %s
+  kubernetes:
+    scheduler:
+      enabled: true
+    controllerManager:
+      enabled: true
+    etcd:
+      enabled: true
+      clientCertSecret: etcd-client
+    coreDNS:
+      enabled: true
+    kubeProxy:
+      enabled: true
+`
+
+// profile is what a provider changes in the rendered Cell
+type profile struct {
+	mountPointsExclude string
+	nodeLabels         string
+	// nodePoolLabel is the kube-state-metrics label the nodepool label is taken from, if any
+	nodePoolLabel string
+	monitored     []string
+	// kubeProxySelector finds the kube-proxy pods, if they're monitored
+	kubeProxySelector map[string]string
+}
+
+var _ = Describe("Provider profiles", func() {
+	var docs []string
+
+	// decode reads the rendered object of the given kind and name into obj
+	decode := func(kind, name string, obj interface{}) {
+		for _, doc := range docs {
+			var o object
+			Expect(yaml.Unmarshal([]byte(doc), &o)).To(Succeed())
+			if o.Kind == kind && o.Metadata.Name == name {
+				Expect(yaml.Unmarshal([]byte(doc), obj)).To(Succeed())
+				return
+			}
+		}
+		Fail(fmt.Sprintf("no %s %s rendered", kind, name))
+	}
+	// monitored lists the Kubernetes components with a ServiceMonitor
+	monitored := func() []string {
+		var components []string
+		for _, name := range []string{"kube-scheduler", "kube-controller-manager", "etcd", "coredns", "kube-proxy"} {
+			for _, doc := range docs {
+				var o object
+				Expect(yaml.Unmarshal([]byte(doc), &o)).To(Succeed())
+				if o.Kind == "ServiceMonitor" && o.Metadata.Name == name+"-cell" {
+					components = append(components, name)
+				}
+			}
+		}
+		return components
+	}
+	arg := func(args []string, flag string) string {
+		for _, a := range args {
+			if strings.HasPrefix(a, flag+"=") {
+				return strings.TrimPrefix(a, flag+"=")
+			}
+		}
+		return ""
+	}
+
+	DescribeTable("rendered components",
+		func(provider string, expected profile) {
+			docs, _ = renderDocs(fmt.Sprintf(providerCell, provider))
+
+			ds := &appsv1.DaemonSet{}
+			decode("DaemonSet", "node-exporter-cell", ds)
+			Expect(arg(ds.Spec.Template.Spec.Containers[0].Args, "--collector.filesystem.mount-points-exclude")).To(Equal(expected.mountPointsExclude))
+
+			deployment := &appsv1.Deployment{}
+			decode("Deployment", "kube-state-metrics-cell", deployment)
+			Expect(arg(deployment.Spec.Template.Spec.Containers[0].Args, "--metric-labels-allowlist")).To(HavePrefix("nodes=[" + expected.nodeLabels + "]"))
+
+			sm := &monitoringv1.ServiceMonitor{}
+			decode("ServiceMonitor", "kube-state-metrics-cell", sm)
+			var nodePool []monitoringv1.LabelName
+			for _, relabel := range sm.Spec.Endpoints[0].MetricRelabelConfigs {
+				if relabel.TargetLabel == "nodepool" {
+					nodePool = relabel.SourceLabels
+				}
+			}
+			if expected.nodePoolLabel == "" {
+				Expect(nodePool).To(BeEmpty())
+			} else {
+				Expect(nodePool).To(ConsistOf(monitoringv1.LabelName(expected.nodePoolLabel)))
+			}
+
+			Expect(monitored()).To(Equal(expected.monitored))
+			if expected.kubeProxySelector != nil {
+				svc := &corev1.Service{}
+				decode("Service", "kube-proxy-monitoring-cell", svc)
+				Expect(svc.Spec.Selector).To(Equal(expected.kubeProxySelector))
+			}
+		},
+		Entry("generic", "generic", profile{
+			mountPointsExclude: "^/(dev|proc|sys|run/k3s/containerd/.+|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
+			nodeLabels:         "topology.kubernetes.io/region",
+			monitored:          []string{"kube-scheduler", "kube-controller-manager", "etcd", "coredns", "kube-proxy"},
+			kubeProxySelector:  map[string]string{"k8s-app": "kube-proxy"},
+		}),
+		Entry("gke", "gke", profile{
+			mountPointsExclude: "^/(dev|proc|sys|home/kubernetes/containerized_mounter/.+|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
+			nodeLabels:         "cloud.google.com/gke-nodepool,topology.kubernetes.io/region",
+			nodePoolLabel:      "label_cloud_google_com_gke_nodepool",
+			monitored:          []string{"coredns", "kube-proxy"},
+			kubeProxySelector:  map[string]string{"component": "kube-proxy"},
+		}),
+		Entry("eks", "eks", profile{
+			mountPointsExclude: "^/(dev|proc|sys|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
+			nodeLabels:         "eks.amazonaws.com/nodegroup,topology.kubernetes.io/region",
+			nodePoolLabel:      "label_eks_amazonaws_com_nodegroup",
+			monitored:          []string{"coredns", "kube-proxy"},
+			kubeProxySelector:  map[string]string{"k8s-app": "kube-proxy"},
+		}),
+		Entry("aks", "aks", profile{
+			mountPointsExclude: "^/(dev|proc|sys|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
+			nodeLabels:         "kubernetes.azure.com/agentpool,topology.kubernetes.io/region",
+			nodePoolLabel:      "label_kubernetes_azure_com_agentpool",
+			monitored:          []string{"coredns", "kube-proxy"},
+			kubeProxySelector:  map[string]string{"k8s-app": "kube-proxy"},
+		}),
+		Entry("k3s", "k3s", profile{
+			mountPointsExclude: "^/(dev|proc|sys|run/k3s/containerd/.+|var/lib/rancher/k3s/agent/containerd/.+|var/lib/kubelet/pods/.+)($|/)",
+			nodeLabels:         "topology.kubernetes.io/region",
+			monitored:          []string{"coredns"},
+		}),
+	)
+})
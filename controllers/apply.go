@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/kubernetes"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/networkpolicy"
+	nodeexporter "github.com/gitpod-io/monitoring-cell/pkg/components/node-exporter"
+)
+
+// reconcileObjects creates or updates each of the desired objects of a component, stopping at the first error.
+// prometheus-operator resources are held back until the operator is ready to act on them, and the rollouts
+// of changes to workloads are tracked, see rolloutTarget.
+func (r *CellReconciler) reconcileObjects(ctx context.Context, cell *monitoringv1beta1.Cell, component string, desired []client.Object) error {
+	operatorReady := cell.Status.PrometheusOperatorReady != nil && *cell.Status.PrometheusOperatorReady
+
+	// Count every desired object, not only those applied this pass, so the gauge doesn't dip while
+	// resources are held back.
+	childObjects.WithLabelValues(cell.Namespace, cell.Name, component).Set(float64(len(desired)))
+
+	deferred := 0
+	now := time.Now()
+	for _, obj := range desired {
+		if !operatorReady && requiresOperator(obj) {
+			deferred++
+			continue
+		}
+		obj, err := r.rolloutTarget(ctx, cell, obj, now)
+		if err != nil {
+			return err
+		}
+		if err := r.reconcileObject(ctx, cell, obj); err != nil {
+			return err
+		}
+	}
+	if deferred > 0 {
+		r.Logger.Info("Waiting for prometheus-operator before applying its resources", "component", component, "deferred", deferred)
+	}
+
+	return nil
+}
+
+// requiresOperator reports whether obj is a prometheus-operator resource, which has no effect until the operator runs.
+func requiresOperator(obj client.Object) bool {
+	return obj.GetObjectKind().GroupVersionKind().Group == pomonitoringv1.SchemeGroupVersion.Group
+}
+
+// reconcileObject creates desired if it doesn't exist yet, or brings the live object in line with it.
+// Every change is recorded as an event on the cell.
+func (r *CellReconciler) reconcileObject(ctx context.Context, cell *monitoringv1beta1.Cell, desired client.Object) error {
+	kind := desired.GetObjectKind().GroupVersionKind().Kind
+	ref := objectRef(desired.GetNamespace(), desired.GetName())
+
+	current := reflect.New(reflect.TypeOf(desired).Elem()).Interface().(client.Object)
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), current)
+	if client.IgnoreNotFound(err) != nil {
+		r.Logger.Error(err, "unable to get child object", "kind", kind, "name", ref)
+		return err
+	}
+
+	if apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, desired); err != nil {
+			r.Logger.Error(err, "failed to create child object", "kind", kind, "name", ref)
+			applyErrors.WithLabelValues(cell.Namespace, cell.Name, kind).Inc()
+			r.Recorder.Eventf(cell, corev1.EventTypeWarning, "CreateFailed", "Failed to create %s %s: %v", kind, ref, err)
+			return err
+		}
+		r.Recorder.Eventf(cell, corev1.EventTypeNormal, "Created", "Created %s %s", kind, ref)
+		return nil
+	}
+
+	if err := mutate(current, desired); err != nil {
+		return err
+	}
+	resourceVersion := current.GetResourceVersion()
+	if err := r.Update(ctx, current); err != nil {
+		r.Logger.Error(err, "failed to update child object", "kind", kind, "name", ref)
+		applyErrors.WithLabelValues(cell.Namespace, cell.Name, kind).Inc()
+		if apierrors.IsConflict(err) {
+			r.Recorder.Eventf(cell, corev1.EventTypeWarning, "Conflict", "%s %s was modified concurrently, retrying: %v", kind, ref, err)
+		} else {
+			r.Recorder.Eventf(cell, corev1.EventTypeWarning, "UpdateFailed", "Failed to update %s %s: %v", kind, ref, err)
+		}
+		return err
+	}
+	// The API server doesn't bump the resourceVersion of updates that don't change anything,
+	// which keeps us from recording an event on every reconcile.
+	if current.GetResourceVersion() != resourceVersion {
+		r.Recorder.Eventf(cell, corev1.EventTypeNormal, "Updated", "Updated %s %s", kind, ref)
+	}
+
+	return nil
+}
+
+// prune deletes the objects of the cell that are no longer desired, see prunable.
+func (r *CellReconciler) prune(ctx context.Context, cell *monitoringv1beta1.Cell, desired []client.Object) error {
+	pruned, err := r.prunable(ctx, cell, desired)
+	if err != nil {
+		return err
+	}
+	for _, obj := range pruned {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		ref := objectRef(obj.GetNamespace(), obj.GetName())
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			r.Logger.Error(err, "failed to delete child object", "kind", kind, "name", ref)
+			r.Recorder.Eventf(cell, corev1.EventTypeWarning, "DeleteFailed", "Failed to delete %s %s: %v", kind, ref, err)
+			return err
+		}
+		r.Recorder.Eventf(cell, corev1.EventTypeNormal, "Deleted", "Deleted %s %s", kind, ref)
+		forgetRollout(cell, kind, obj.GetName())
+	}
+
+	return nil
+}
+
+// prunable lists the objects of the cell that are no longer desired: the DaemonSet of a removed node-exporter
+// profile, the kube-state-metrics Deployment once it's sharded, or the monitor of a Kubernetes component that
+// was disabled. Left behind, workloads would keep the desired ones from binding their host port, or export
+// the same metrics twice.
+func (r *CellReconciler) prunable(ctx context.Context, cell *monitoringv1beta1.Cell, desired []client.Object) ([]client.Object, error) {
+	keep := map[string]bool{}
+	for _, obj := range desired {
+		keep[obj.GetObjectKind().GroupVersionKind().Kind+"/"+obj.GetNamespace()+"/"+obj.GetName()] = true
+	}
+
+	exporters, err := labels.NewRequirement("app.kubernetes.io/name", selection.In, []string{nodeexporter.Name, kubestatemetrics.Name})
+	if err != nil {
+		return nil, err
+	}
+	exporterSelector := client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*exporters)}
+	var names []string
+	for _, component := range []kubernetes.Component{kubernetes.Scheduler, kubernetes.ControllerManager, kubernetes.Etcd, kubernetes.CoreDNS, kubernetes.KubeProxy} {
+		names = append(names, component.Name)
+	}
+	components, err := labels.NewRequirement("app.kubernetes.io/name", selection.In, names)
+	if err != nil {
+		return nil, err
+	}
+	componentSelector := client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*components)}
+
+	candidates := []struct {
+		list client.ObjectList
+		opts []client.ListOption
+	}{
+		{&appsv1.DaemonSetList{}, []client.ListOption{client.InNamespace(cell.Namespace), exporterSelector}},
+		{&appsv1.DeploymentList{}, []client.ListOption{client.InNamespace(cell.Namespace), exporterSelector}},
+		{&appsv1.StatefulSetList{}, []client.ListOption{client.InNamespace(cell.Namespace), exporterSelector}},
+		{&corev1.ServiceList{}, []client.ListOption{client.InNamespace(kubernetes.Namespace), client.MatchingLabels{kubernetes.CellLabel: cell.Name, kubernetes.CellNamespaceLabel: cell.Namespace}}},
+		{&pomonitoringv1.ServiceMonitorList{}, []client.ListOption{client.InNamespace(cell.Namespace), componentSelector}},
+		// The NetworkPolicies of the strict mode, once the cell leaves it
+		{&networkv1.NetworkPolicyList{}, []client.ListOption{client.InNamespace(cell.Namespace), client.MatchingLabels{"app.kubernetes.io/name": networkpolicy.Name}}},
+	}
+	var pruned []client.Object
+	for _, candidate := range candidates {
+		if err := r.List(ctx, candidate.list, candidate.opts...); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(candidate.list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			obj := item.(client.Object)
+			gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+			if err != nil {
+				return nil, err
+			}
+			if keep[gvk.Kind+"/"+obj.GetNamespace()+"/"+obj.GetName()] || !ownedBy(obj, cell) {
+				continue
+			}
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+			pruned = append(pruned, obj)
+		}
+	}
+
+	return pruned, nil
+}
+
+// ownedBy reports whether obj belongs to the cell, either by owner reference or, for objects outside the
+// cell's namespace, by label.
+func ownedBy(obj client.Object, cell *monitoringv1beta1.Cell) bool {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.UID == cell.UID {
+			return true
+		}
+	}
+
+	return obj.GetLabels()[kubernetes.CellLabel] == cell.Name && obj.GetLabels()[kubernetes.CellNamespaceLabel] == cell.Namespace
+}
+
+// mutate copies the fields the controller owns from desired onto current.
+func mutate(current, desired client.Object) error {
+	current.SetLabels(desired.GetLabels())
+
+	switch c := current.(type) {
+	case *rbacv1.ClusterRole:
+		c.Rules = desired.(*rbacv1.ClusterRole).Rules
+	case *rbacv1.ClusterRoleBinding:
+		d := desired.(*rbacv1.ClusterRoleBinding)
+		c.Subjects = d.Subjects
+		c.RoleRef = d.RoleRef
+	case *rbacv1.Role:
+		c.Rules = desired.(*rbacv1.Role).Rules
+	case *rbacv1.RoleBinding:
+		d := desired.(*rbacv1.RoleBinding)
+		c.Subjects = d.Subjects
+		c.RoleRef = d.RoleRef
+	case *corev1.ServiceAccount:
+		c.AutomountServiceAccountToken = desired.(*corev1.ServiceAccount).AutomountServiceAccountToken
+	case *corev1.Service:
+		c.Spec = desired.(*corev1.Service).Spec
+	case *corev1.ConfigMap:
+		c.Data = desired.(*corev1.ConfigMap).Data
+	case *appsv1.Deployment:
+		c.Spec = desired.(*appsv1.Deployment).Spec
+	case *appsv1.DaemonSet:
+		c.Spec = desired.(*appsv1.DaemonSet).Spec
+	case *appsv1.StatefulSet:
+		c.Spec = desired.(*appsv1.StatefulSet).Spec
+	case *networkv1.NetworkPolicy:
+		c.Spec = desired.(*networkv1.NetworkPolicy).Spec
+	case *pomonitoringv1.ServiceMonitor:
+		c.Spec = desired.(*pomonitoringv1.ServiceMonitor).Spec
+	case *pomonitoringv1.Prometheus:
+		c.Spec = desired.(*pomonitoringv1.Prometheus).Spec
+	default:
+		return fmt.Errorf("unsupported child object type %T", current)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("reconcileObject", func() {
+	var (
+		ctx      = context.Background()
+		cell     *monitoringv1beta1.Cell
+		r        *CellReconciler
+		recorder *record.FakeRecorder
+	)
+
+	configMap := func(value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-state-metrics-cell", Namespace: "monitoring"},
+			Data:       map[string]string{"config.yaml": value},
+		}
+	}
+	events := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		return events
+	}
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "monitoring"}}
+		recorder = record.NewFakeRecorder(10)
+		r = &CellReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+			Logger:   logr.Discard(),
+			Recorder: recorder,
+		}
+	})
+
+	It("records creating an object", func() {
+		Expect(r.reconcileObject(ctx, cell, configMap("a"))).To(Succeed())
+		Expect(events()).To(Equal([]string{"Normal Created Created ConfigMap monitoring/kube-state-metrics-cell"}))
+	})
+
+	It("records updating an object", func() {
+		Expect(r.Create(ctx, configMap("a"))).To(Succeed())
+
+		Expect(r.reconcileObject(ctx, cell, configMap("b"))).To(Succeed())
+		Expect(events()).To(Equal([]string{"Normal Updated Updated ConfigMap monitoring/kube-state-metrics-cell"}))
+	})
+
+	It("records nothing for updates that don't change the object", func() {
+		Expect(r.Create(ctx, configMap("a"))).To(Succeed())
+		// The API server keeps the resourceVersion of updates that don't change anything, the fake client doesn't
+		r.Client = stubWrites{Client: r.Client, update: func(client.Object) error { return nil }}
+
+		Expect(r.reconcileObject(ctx, cell, configMap("a"))).To(Succeed())
+		Expect(events()).To(BeEmpty())
+	})
+
+	It("records failing to create an object", func() {
+		r.Client = stubWrites{Client: r.Client, create: func(client.Object) error { return errors.New("quota exceeded") }}
+
+		Expect(r.reconcileObject(ctx, cell, configMap("a"))).To(MatchError("quota exceeded"))
+		Expect(events()).To(Equal([]string{"Warning CreateFailed Failed to create ConfigMap monitoring/kube-state-metrics-cell: quota exceeded"}))
+	})
+
+	It("records failing to update an object", func() {
+		Expect(r.Create(ctx, configMap("a"))).To(Succeed())
+		r.Client = stubWrites{Client: r.Client, update: func(client.Object) error { return errors.New("admission denied") }}
+
+		Expect(r.reconcileObject(ctx, cell, configMap("b"))).To(MatchError("admission denied"))
+		Expect(events()).To(Equal([]string{"Warning UpdateFailed Failed to update ConfigMap monitoring/kube-state-metrics-cell: admission denied"}))
+	})
+
+	It("records conflicting updates as such", func() {
+		Expect(r.Create(ctx, configMap("a"))).To(Succeed())
+		conflict := apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "kube-state-metrics-cell", errors.New("object was modified"))
+		r.Client = stubWrites{Client: r.Client, update: func(client.Object) error { return conflict }}
+
+		Expect(apierrors.IsConflict(r.reconcileObject(ctx, cell, configMap("b")))).To(BeTrue())
+		Expect(events()).To(ConsistOf(HavePrefix("Warning Conflict ConfigMap monitoring/kube-state-metrics-cell was modified concurrently, retrying")))
+	})
+})
+
+// stubWrites answers creates and updates through the client with the given functions, if set.
+type stubWrites struct {
+	client.Client
+	create func(client.Object) error
+	update func(client.Object) error
+}
+
+func (c stubWrites) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if c.create != nil {
+		return c.create(obj)
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c stubWrites) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if c.update != nil {
+		return c.update(obj)
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// notReadyBaseDelay is how soon a cell that isn't ready yet is checked again the first time
+	notReadyBaseDelay = 10 * time.Second
+	// notReadyMaxDelay caps the backoff between checks of a cell that stays not ready
+	notReadyMaxDelay = 5 * time.Minute
+	// readyResync is how often the status of a ready cell is refreshed
+	readyResync = 10 * time.Minute
+	// jitterFactor spreads requeues of many cells out, so they don't all query their Prometheus at once
+	jitterFactor = 0.2
+)
+
+// backoff schedules the requeues of cells that aren't ready. Every attempt doubles the delay until
+// the cell is ready or its spec changes.
+type backoff struct {
+	mu    sync.Mutex
+	cells map[types.NamespacedName]backoffState
+}
+
+type backoffState struct {
+	generation int64
+	attempts   int
+}
+
+// next returns the delay until a not ready cell should be checked again, and counts the attempt.
+func (b *backoff) next(cell types.NamespacedName, generation int64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cells == nil {
+		b.cells = map[types.NamespacedName]backoffState{}
+	}
+
+	state := b.cells[cell]
+	if state.generation != generation {
+		state = backoffState{generation: generation}
+	}
+	delay := notReadyBaseDelay
+	for i := 0; i < state.attempts && delay < notReadyMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > notReadyMaxDelay {
+		delay = notReadyMaxDelay
+	}
+	state.attempts++
+	b.cells[cell] = state
+
+	return wait.Jitter(delay, jitterFactor)
+}
+
+// reset starts the schedule of a cell over, e.g. once it became ready or was deleted.
+func (b *backoff) reset(cell types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.cells, cell)
+}
+
+// resyncAfter returns when a ready cell should be looked at again.
+func resyncAfter() time.Duration {
+	return wait.Jitter(readyResync, jitterFactor)
+}
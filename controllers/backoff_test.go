@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Backoff", func() {
+	var (
+		b    *backoff
+		cell = types.NamespacedName{Namespace: "default", Name: "cell"}
+	)
+
+	BeforeEach(func() {
+		b = &backoff{}
+	})
+
+	// beAbout matches a delay jittered up from d.
+	beAbout := func(d time.Duration) OmegaMatcher {
+		return And(BeNumerically(">=", d), BeNumerically("<=", time.Duration(float64(d)*(1+jitterFactor))))
+	}
+
+	It("doubles the delay between checks of a cell that stays not ready, up to the maximum", func() {
+		Expect(b.next(cell, 1)).To(beAbout(notReadyBaseDelay))
+		Expect(b.next(cell, 1)).To(beAbout(2 * notReadyBaseDelay))
+		Expect(b.next(cell, 1)).To(beAbout(4 * notReadyBaseDelay))
+		for i := 0; i < 10; i++ {
+			b.next(cell, 1)
+		}
+		Expect(b.next(cell, 1)).To(beAbout(notReadyMaxDelay))
+	})
+
+	It("starts over when the spec of the cell changes", func() {
+		b.next(cell, 1)
+		b.next(cell, 1)
+		Expect(b.next(cell, 2)).To(beAbout(notReadyBaseDelay))
+	})
+
+	It("starts over once the cell was reset", func() {
+		b.next(cell, 1)
+		b.next(cell, 1)
+		b.reset(cell)
+		Expect(b.next(cell, 1)).To(beAbout(notReadyBaseDelay))
+	})
+
+	It("keeps a schedule per cell", func() {
+		b.next(cell, 1)
+		b.next(cell, 1)
+		Expect(b.next(types.NamespacedName{Namespace: "default", Name: "other"}, 1)).To(beAbout(notReadyBaseDelay))
+	})
+})
@@ -21,49 +21,83 @@ import (
 	"fmt"
 	"time"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
-	"github.com/gitpod-io/monitoring-cell/pkg/components/gitpod"
-	kubernetes "github.com/gitpod-io/monitoring-cell/pkg/components/kubernetes"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components"
 	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
-	nodeexporter "github.com/gitpod-io/monitoring-cell/pkg/components/node-exporter"
 	"github.com/gitpod-io/monitoring-cell/pkg/components/prometheus"
 	prometheusoperator "github.com/gitpod-io/monitoring-cell/pkg/components/prometheus-operator"
 	"github.com/go-logr/logr"
 	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	prom "github.com/prometheus/client_golang/prometheus"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	networkv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var (
 	POOwnerKey = ".metadata.controller"
-	apiGVStr   = monitoringv1alpha1.GroupVersion.String()
+	apiGVStr   = monitoringv1beta1.GroupVersion.String()
 )
 
 // CellReconciler reconciles a Cell object
 type CellReconciler struct {
 	client.Client
 	PodRESTClient rest.Interface
-	RESTConfig    *rest.Config
-	Scheme        *runtime.Scheme
-	Logger        logr.Logger
+	// APIReader reads from the API server directly, for objects that aren't worth caching
+	APIReader  client.Reader
+	RESTConfig *rest.Config
+	Scheme     *runtime.Scheme
+	Logger     logr.Logger
+	Recorder   record.EventRecorder
+
+	// DryRun makes the controller report the changes it would make to every Cell's objects instead of applying them
+	DryRun bool
+
+	// StallTimeout is how long the reconcile loop may go without progress before the liveness check fails
+	StallTimeout time.Duration
+
+	progress progress
+	backoff  backoff
+}
+
+// reader returns the APIReader, or the client where there's none, e.g. in tests.
+func (r *CellReconciler) reader() client.Reader {
+	if r.APIReader == nil {
+		return r.Client
+	}
+
+	return r.APIReader
 }
 
 //+kubebuilder:rbac:groups=monitoring.gitpod.io,resources=cells,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=monitoring.gitpod.io,resources=cells/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=monitoring.gitpod.io,resources=cells/finalizers,verbs=update
+//+kubebuilder:rbac:groups=,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=,resources=secrets,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups=,resources=pods,verbs=list
+//+kubebuilder:rbac:groups=,resources=nodes,verbs=list
+//+kubebuilder:rbac:groups=,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheuses,verbs=get;list;watch;create;update;patch;delete
@@ -76,33 +110,131 @@ type CellReconciler struct {
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.13.1/pkg/reconcile
 func (r *CellReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	r.Logger = log.FromContext(ctx)
-	var cell monitoringv1alpha1.Cell
+	r.progress.start()
+	defer r.progress.done()
+
+	var cell monitoringv1beta1.Cell
 
 	if err := r.Get(ctx, req.NamespacedName, &cell); err != nil {
 		r.Logger.Error(err, "Unable to fetch Cell")
+		if apierrors.IsNotFound(err) {
+			forgetCellMetrics(req.NamespacedName)
+			r.backoff.reset(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	// The owner references of the cell's objects are built from its TypeMeta, which only the cache fills in.
+	cell.SetGroupVersionKind(monitoringv1beta1.GroupVersion.WithKind("Cell"))
 
-	err := r.updateCellStatus(ctx, &cell)
+	missing, err := missingCRDs(r.RESTMapper())
 	if err != nil {
-		r.Logger.Error(err, "Unable to update Cell status")
+		r.Logger.Error(err, "Unable to check for required CRDs")
 		return ctrl.Result{}, err
 	}
-
-	if !*cell.Status.PrometheusOperatorReady {
-		err = r.reconcilePrometheusOperator(ctx, &cell, req)
-		if err != nil {
-			r.Logger.Error(err, "Failed to reconcile Prometheus-Operator")
+	if len(missing) > 0 {
+		// Without the prometheus-operator CRDs there's nothing we can reconcile. Report it on the Cell
+		// and check again later instead of failing, so the controller doesn't crash-loop.
+		r.Logger.Info("Required CRDs are missing", "crds", missing)
+		r.Recorder.Event(&cell, corev1.EventTypeWarning, "CRDsMissing", missingCRDsMessage(missing))
+		meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+			Type:    monitoringv1beta1.CRDsMissing,
+			Status:  metav1.ConditionTrue,
+			Reason:  "CRDsNotInstalled",
+			Message: missingCRDsMessage(missing),
+		})
+		meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+			Type:    monitoringv1beta1.Ready,
+			Status:  metav1.ConditionFalse,
+			Reason:  "CRDsMissing",
+			Message: missingCRDsMessage(missing),
+		})
+		if err := r.writeStatus(ctx, &cell); err != nil {
+			r.Logger.Error(err, "Unable to update Cell status")
 			return ctrl.Result{}, err
 		}
+		recordStatusMetrics(&cell)
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
 	}
+	meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+		Type:    monitoringv1beta1.CRDsMissing,
+		Status:  metav1.ConditionFalse,
+		Reason:  "CRDsInstalled",
+		Message: "all required CRDs are installed",
+	})
 
-	if !*cell.Status.PrometheusReady {
-		err = r.reconcilePrometheus(ctx, &cell, req)
-		if err != nil {
-			r.Logger.Error(err, "Failed to reconcile Prometheus")
+	if err := r.checkRemoteWriteCredentials(ctx, &cell); err != nil {
+		r.Logger.Error(err, "Unable to check remote-write credentials")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.discoverKubernetesComponents(ctx, &cell); err != nil {
+		r.Logger.Error(err, "Unable to discover Kubernetes components")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.resolveCustomResources(&cell); err != nil {
+		r.Logger.Error(err, "Unable to resolve kube-state-metrics custom resources")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.checkNodeExporterProfiles(ctx, &cell); err != nil {
+		r.Logger.Error(err, "Unable to check node-exporter profiles")
+		return ctrl.Result{}, err
+	}
+
+	if r.isDryRun(&cell) {
+		if err := r.dryRun(ctx, &cell); err != nil {
+			r.Logger.Error(err, "Failed to dry-run Cell")
+			return ctrl.Result{}, err
+		}
+		if err := r.updateCellStatus(ctx, &cell); err != nil {
+			r.Logger.Error(err, "Unable to update Cell status")
 			return ctrl.Result{}, err
 		}
+		// Drift of the cell's objects doesn't trigger a reconcile, so look again later.
+		return ctrl.Result{RequeueAfter: resyncAfter()}, nil
+	}
+	cell.Status.DryRun = nil
+
+	err = r.updateCellStatus(ctx, &cell)
+	if err != nil {
+		r.Logger.Error(err, "Unable to update Cell status")
+		return ctrl.Result{}, err
+	}
+
+	// Applying the cell's objects records the rollouts of its workloads in the status.
+	status := cell.Status.DeepCopy()
+
+	if now := time.Now(); isPaused(&cell, now) {
+		r.Logger.Info("Cell is paused, not changing any of its objects")
+		return ctrl.Result{RequeueAfter: pausedRequeueAfter(&cell, now)}, nil
+	}
+
+	// Certificates go first, so the pods mounting them can start.
+	if err := r.reconcileCertificates(ctx, &cell); err != nil {
+		r.Logger.Error(err, "Failed to reconcile certificates")
+		return ctrl.Result{}, err
+	}
+
+	// Every component is converged on every reconcile, so changes to the Cell and drift of its objects are
+	// picked up even when everything is healthy. Readiness only holds back the resources that depend on
+	// prometheus-operator, see reconcileObjects.
+	err = r.reconcileNetworkPolicies(ctx, &cell, req)
+	if err != nil {
+		r.Logger.Error(err, "Failed to reconcile network policies")
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcilePrometheusOperator(ctx, &cell, req)
+	if err != nil {
+		r.Logger.Error(err, "Failed to reconcile Prometheus-Operator")
+		return ctrl.Result{}, err
+	}
+
+	err = r.reconcilePrometheus(ctx, &cell, req)
+	if err != nil {
+		r.Logger.Error(err, "Failed to reconcile Prometheus")
+		return ctrl.Result{}, err
 	}
 
 	err = r.reconcileGitpodMonitoring(ctx, &cell, req)
@@ -117,18 +249,47 @@ func (r *CellReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		return ctrl.Result{}, err
 	}
 
+	if err := r.prune(ctx, &cell, components.Objects(&cell)); err != nil {
+		r.Logger.Error(err, "Failed to delete objects that are no longer desired")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.trackRollouts(ctx, &cell, time.Now()); err != nil {
+		r.Logger.Error(err, "Failed to track rollouts")
+		return ctrl.Result{}, err
+	}
+	if !equality.Semantic.DeepEqual(status, &cell.Status) {
+		if err := r.writeStatus(ctx, &cell); err != nil {
+			r.Logger.Error(err, "Unable to update Cell status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if *cell.Status.PrometheusOperatorReady {
+		markReconciled(&cell)
+	}
+	var result ctrl.Result
 	if !*cell.Status.PrometheusReady ||
 		!*cell.Status.APIServerReady ||
 		!*cell.Status.KubeletReady ||
 		!*cell.Status.NodeExporterReady ||
 		!*cell.Status.KubeStateMetricsReady {
-		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		result.RequeueAfter = r.backoff.next(req.NamespacedName, cell.Generation)
+	} else {
+		r.backoff.reset(req.NamespacedName)
+		result.RequeueAfter = resyncAfter()
 	}
-	return ctrl.Result{}, nil
+	if progressingRollout(&cell) != nil && result.RequeueAfter > rolloutPoll {
+		result.RequeueAfter = rolloutPoll
+	}
+	return result, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CellReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Nothing is stalled before the controller even started.
+	r.progress.done()
+
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &appsv1.Deployment{}, POOwnerKey, func(rawObject client.Object) []string {
 		deployment := rawObject.(*appsv1.Deployment)
 		owner := metav1.GetControllerOf(deployment)
@@ -209,6 +370,17 @@ func (r *CellReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	// Indexing a kind that isn't served fails and would keep the manager from starting.
+	// Reconcile reports the missing CRDs on the Cell instead.
+	missing, err := missingCRDs(mgr.GetRESTMapper())
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		mgr.GetLogger().Info("skipping prometheus-operator field indexers", "reason", missingCRDsMessage(missing))
+		return r.complete(mgr)
+	}
+
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &pomonitoringv1.ServiceMonitor{}, POOwnerKey, func(rawObject client.Object) []string {
 		serviceMonitor := rawObject.(*pomonitoringv1.ServiceMonitor)
 		owner := metav1.GetControllerOf(serviceMonitor)
@@ -241,12 +413,28 @@ func (r *CellReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	return r.complete(mgr)
+}
+
+func (r *CellReconciler) complete(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&monitoringv1alpha1.Cell{}).
+		Named(controllerName).
+		// The status is rewritten on every pass with values read from Prometheus, which must not trigger
+		// another one. Changes to the dry-run annotation and to the labels copied to the cell's objects do.
+		For(&monitoringv1beta1.Cell{}, builder.WithPredicates(predicate.Or(
+			predicate.GenerationChangedPredicate{},
+			predicate.AnnotationChangedPredicate{},
+			predicate.LabelChangedPredicate{},
+		))).
+		// Rotated remote-write credentials have to reach Prometheus. Only the metadata of Secrets labelled
+		// with monitoring.gitpod.io/credentials is cached, see main.go, and values are read from the API server.
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.cellsForSecret), builder.OnlyMetadata).
 		Complete(r)
 }
 
-func (r *CellReconciler) updateCellStatus(ctx context.Context, cell *monitoringv1alpha1.Cell) error {
+func (r *CellReconciler) updateCellStatus(ctx context.Context, cell *monitoringv1beta1.Cell) error {
+	previous := cell.Status.DeepCopy()
+
 	poReady, err := r.isPrometheusOperatorReady(ctx, cell)
 	if err != nil {
 		r.Logger.Error(err, "Failed to get Prometheus-operator Status")
@@ -269,7 +457,8 @@ func (r *CellReconciler) updateCellStatus(ctx context.Context, cell *monitoringv
 		}
 		cell.Status.NodeExporterReady = &neReady
 
-		ksmReady, err := r.isExporterReady(ctx, cell, `up{job="kube-state-metrics"} == 1`, 2)
+		// Each replica of kube-state-metrics is scraped twice, for its metrics and its own telemetry
+		ksmReady, err := r.isExporterReady(ctx, cell, `up{job="kube-state-metrics"} == 1`, 2*int(kubestatemetrics.Shards(cell)))
 		if err != nil {
 			r.Logger.Error(err, "Failed to fetch kubestate-metrics' metrics")
 			return err
@@ -290,157 +479,37 @@ func (r *CellReconciler) updateCellStatus(ctx context.Context, cell *monitoringv
 		}
 		cell.Status.APIServerReady = &apiserverReady
 	}
+	r.checkRemoteWriteHealth(cell)
 
-	return r.Status().Update(ctx, cell)
-}
-
-func (r *CellReconciler) reconcilePrometheusOperator(ctx context.Context, cell *monitoringv1alpha1.Cell, req ctrl.Request) error {
-
-	/** Cluster Role **/
-	var clusterRole rbacv1.ClusterRole
-	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name), Namespace: cell.Namespace}, &clusterRole)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child clusterrole")
+	meta.SetStatusCondition(&cell.Status.Conditions, readyCondition(cell, time.Now()))
+	if err := r.writeStatus(ctx, cell); err != nil {
 		return err
 	}
-	desiredClusterRole := prometheusoperator.ClusterRole(cell)
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredClusterRole); err != nil {
-			r.Logger.Error(err, "failed to create prometheus-operator's ClusterRole")
-			return err
-		}
-	} else {
-		clusterRole.Labels = desiredClusterRole.Labels
-		clusterRole.Name = desiredClusterRole.Name
-		clusterRole.Rules = desiredClusterRole.Rules
-		if err := r.Update(ctx, &clusterRole); err != nil {
-			r.Logger.Error(err, "failed to update prometheus-operator's ClusterRole")
-			return err
-		}
-	}
-
-	/** ClusterRoleBinding **/
-	var clusterRoleBinding rbacv1.ClusterRoleBinding
-	err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name), Namespace: cell.Namespace}, &clusterRoleBinding)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child clusterrolebinding")
-		return err
-	}
-	desiredClusterRoleBinding := prometheusoperator.ClusterRoleBinding(cell)
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredClusterRoleBinding); err != nil {
-			r.Logger.Error(err, "failed to create prometheus-operator's ClusterRoleBinding")
-			return err
-		}
-	} else {
-		clusterRoleBinding.Labels = desiredClusterRoleBinding.Labels
-		clusterRoleBinding.Name = desiredClusterRoleBinding.Name
-		clusterRoleBinding.Subjects = desiredClusterRoleBinding.Subjects
-		clusterRoleBinding.RoleRef = desiredClusterRoleBinding.RoleRef
-		if err := r.Update(ctx, &clusterRoleBinding); err != nil {
-			r.Logger.Error(err, "failed to update prometheus-operator's ClusterRoleBinding")
-			return err
-		}
-	}
-
-	/** ServiceAccount **/
-	var serviceAccount corev1.ServiceAccount
-	err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name), Namespace: cell.Namespace}, &serviceAccount)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child serviceaccount")
-		return err
-	}
-	desiredServiceAccount := prometheusoperator.ServiceAccount(cell)
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredServiceAccount); err != nil {
-			r.Logger.Error(err, "failed to create prometheus-operator's ServiceAccount")
-			return err
-		}
-	} else {
-		serviceAccount.Labels = desiredServiceAccount.Labels
-		serviceAccount.Name = desiredServiceAccount.Name
-		serviceAccount.AutomountServiceAccountToken = desiredServiceAccount.AutomountServiceAccountToken
-		if err := r.Update(ctx, &serviceAccount); err != nil {
-			r.Logger.Error(err, "failed to update prometheus-operator's ServiceAccount")
-			return err
-		}
-	}
-
-	/** Service **/
-	var service corev1.Service
-	err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name), Namespace: cell.Namespace}, &service)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child service")
-		return err
-	}
-	desiredService := prometheusoperator.Service(cell)
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredService); err != nil {
-			r.Logger.Error(err, "failed to create prometheus-operator's Service")
-			return err
-		}
-	} else {
-		service.Labels = desiredService.Labels
-		service.Name = desiredService.Name
-		service.Spec = desiredService.Spec
-		if err := r.Update(ctx, &service); err != nil {
-			r.Logger.Error(err, "failed to update prometheus-operator's Service")
-			return err
-		}
-	}
 
-	/** Deployment **/
-	var deployment appsv1.Deployment
-	err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name), Namespace: cell.Namespace}, &deployment)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child deployment")
-		return err
-	}
+	recordStatusMetrics(cell)
+	r.recordReadinessTransitions(cell, previous)
+	return nil
+}
 
-	desiredDeployment := prometheusoperator.Deployment(cell)
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredDeployment); err != nil {
-			r.Logger.Error(err, "failed to create prometheus-operator's Deployment")
-			return err
-		}
-	} else {
-		deployment.Labels = desiredDeployment.Labels
-		deployment.Name = desiredDeployment.Name
-		deployment.Spec = desiredDeployment.Spec
-		if err := r.Update(ctx, &deployment); err != nil {
-			r.Logger.Error(err, "failed to update prometheus-operator's deployment")
-			return err
-		}
+// writeStatus persists the cell status, recording conflicts with concurrent writers as events.
+func (r *CellReconciler) writeStatus(ctx context.Context, cell *monitoringv1beta1.Cell) error {
+	err := r.Status().Update(ctx, cell)
+	if apierrors.IsConflict(err) {
+		r.Recorder.Eventf(cell, corev1.EventTypeWarning, "Conflict", "Cell status was modified concurrently, retrying: %v", err)
 	}
 
-	/** ServiceMonitor **/
-	var serviceMonitor pomonitoringv1.ServiceMonitor
-	err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name), Namespace: cell.Namespace}, &serviceMonitor)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child servicemonitor")
-		return err
-	}
+	return err
+}
 
-	desiredServiceMonitor := prometheusoperator.ServiceMonitor(cell)
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredServiceMonitor); err != nil {
-			r.Logger.Error(err, "failed to create prometheus-operator's ServiceMonitor")
-			return err
-		}
-	} else {
-		serviceMonitor.Labels = desiredServiceMonitor.Labels
-		serviceMonitor.Name = desiredServiceMonitor.Name
-		serviceMonitor.Spec = desiredServiceMonitor.Spec
-		if err := r.Update(ctx, &serviceMonitor); err != nil {
-			r.Logger.Error(err, "failed to update prometheus-operator's ServiceMonitor")
-			return err
-		}
-	}
+func (r *CellReconciler) reconcileNetworkPolicies(ctx context.Context, cell *monitoringv1beta1.Cell, req ctrl.Request) error {
+	return r.reconcileObjects(ctx, cell, "network-policies", components.NetworkPolicies(cell))
+}
 
-	return nil
+func (r *CellReconciler) reconcilePrometheusOperator(ctx context.Context, cell *monitoringv1beta1.Cell, req ctrl.Request) error {
+	return r.reconcileObjects(ctx, cell, "prometheus-operator", components.PrometheusOperator(cell))
 }
 
-func (r *CellReconciler) isPrometheusOperatorReady(ctx context.Context, cell *monitoringv1alpha1.Cell) (bool, error) {
+func (r *CellReconciler) isPrometheusOperatorReady(ctx context.Context, cell *monitoringv1beta1.Cell) (bool, error) {
 	var deployment appsv1.Deployment
 	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name), Namespace: cell.Namespace}, &deployment)
 	if client.IgnoreNotFound(err) != nil {
@@ -456,204 +525,25 @@ func (r *CellReconciler) isPrometheusOperatorReady(ctx context.Context, cell *mo
 	return true, nil
 }
 
-func (r *CellReconciler) reconcilePrometheus(ctx context.Context, cell *monitoringv1alpha1.Cell, req ctrl.Request) error {
-	/** ClusterRole **/
-	var clusterRole rbacv1.ClusterRole
-	desiredClusterRole := prometheus.ClusterRole(cell)
-	err := r.Get(ctx, types.NamespacedName{Name: desiredClusterRole.Name, Namespace: cell.Namespace}, &clusterRole)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child clusterrole")
-		return err
-	}
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredClusterRole); err != nil {
-			r.Logger.Error(err, "failed to create prometheus's ClusterRole")
-			return err
-		}
-	} else {
-		clusterRole.Labels = desiredClusterRole.Labels
-		clusterRole.Name = desiredClusterRole.Name
-		clusterRole.Rules = desiredClusterRole.Rules
-		if err := r.Update(ctx, &clusterRole); err != nil {
-			r.Logger.Error(err, "failed to update prometheus's ClusterRole")
-			return err
-		}
-	}
-
-	/** ClusterRoleBinding **/
-	var clusterRoleBinding rbacv1.ClusterRoleBinding
-	desiredClusterRoleBinding := prometheus.ClusterRoleBinding(cell)
-	err = r.Get(ctx, types.NamespacedName{Name: desiredClusterRoleBinding.Name, Namespace: cell.Namespace}, &clusterRoleBinding)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child clusterRoleBinding")
-		return err
-	}
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredClusterRoleBinding); err != nil {
-			r.Logger.Error(err, "failed to create prometheus's ClusterRoleBinding")
-			return err
-		}
-	} else {
-		clusterRoleBinding.Labels = desiredClusterRoleBinding.Labels
-		clusterRoleBinding.Name = desiredClusterRoleBinding.Name
-		clusterRoleBinding.Subjects = desiredClusterRoleBinding.Subjects
-		clusterRoleBinding.RoleRef = desiredClusterRoleBinding.RoleRef
-		if err := r.Update(ctx, &clusterRoleBinding); err != nil {
-			r.Logger.Error(err, "failed to update prometheus's ClusterRoleBinding")
-			return err
-		}
-	}
-
-	/** Roles **/
-	desiredRoles := prometheus.Roles(cell)
-	var currentRole rbacv1.Role
-	for _, role := range desiredRoles {
-		err = r.Get(ctx, types.NamespacedName{Name: role.Name, Namespace: role.Namespace}, &currentRole)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child Roles")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, role); err != nil {
-				r.Logger.Error(err, "failed to create prometheus's Role")
-				return err
-			}
-		} else {
-			currentRole.Labels = role.Labels
-			currentRole.Name = role.Name
-			currentRole.Rules = role.Rules
-			if err := r.Update(ctx, &currentRole); err != nil {
-				r.Logger.Error(err, "failed to update prometheus's Role")
-				return err
+func (r *CellReconciler) reconcilePrometheus(ctx context.Context, cell *monitoringv1beta1.Cell, req ctrl.Request) error {
+	objects := components.Prometheus(cell)
+	if meta.IsStatusConditionTrue(cell.Status.Conditions, monitoringv1beta1.CredentialsMissing) {
+		// prometheus-operator can't generate a configuration that references missing Secret keys,
+		// so keep Prometheus as it is until they're there.
+		r.Logger.Info("Not updating Prometheus while remote-write credentials are missing")
+		var held []client.Object
+		for _, obj := range objects {
+			if _, ok := obj.(*pomonitoringv1.Prometheus); !ok {
+				held = append(held, obj)
 			}
 		}
+		objects = held
 	}
 
-	/** RoleBindings **/
-	desiredRoleBindings := prometheus.RoleBindings(cell)
-	var currentRoleBinding rbacv1.RoleBinding
-	for _, roleBinding := range desiredRoleBindings {
-		err = r.Get(ctx, types.NamespacedName{Name: roleBinding.Name, Namespace: roleBinding.Namespace}, &currentRoleBinding)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child RoleBindings")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, roleBinding); err != nil {
-				r.Logger.Error(err, "failed to create prometheus's RoleBinding")
-				return err
-			}
-		} else {
-			currentRoleBinding.Labels = roleBinding.Labels
-			currentRoleBinding.Name = roleBinding.Name
-			currentRoleBinding.Subjects = roleBinding.Subjects
-			currentRoleBinding.RoleRef = roleBinding.RoleRef
-			if err := r.Update(ctx, &currentRole); err != nil {
-				r.Logger.Error(err, "failed to update prometheus's RoleBinding")
-				return err
-			}
-		}
-	}
-
-	/** ServiceAccount **/
-	var serviceAccount corev1.ServiceAccount
-	desiredServiceAccount := prometheus.ServiceAccount(cell)
-	err = r.Get(ctx, types.NamespacedName{Name: desiredServiceAccount.Name, Namespace: cell.Namespace}, &serviceAccount)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child serviceaccount")
-		return err
-	}
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredServiceAccount); err != nil {
-			r.Logger.Error(err, "failed to create prometheus's ServiceAccount")
-			return err
-		}
-	} else {
-		serviceAccount.Labels = desiredServiceAccount.Labels
-		serviceAccount.Name = desiredServiceAccount.Name
-		serviceAccount.AutomountServiceAccountToken = desiredServiceAccount.AutomountServiceAccountToken
-		if err := r.Update(ctx, &serviceAccount); err != nil {
-			r.Logger.Error(err, "failed to update prometheus's ServiceAccount")
-			return err
-		}
-	}
-
-	/** Service **/
-	var service corev1.Service
-	desiredService := prometheus.Service(cell)
-	err = r.Get(ctx, types.NamespacedName{Name: desiredService.Name, Namespace: cell.Namespace}, &service)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child service")
-		return err
-	}
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredService); err != nil {
-			r.Logger.Error(err, "failed to create prometheus's Service")
-			return err
-		}
-	} else {
-		service.Labels = desiredService.Labels
-		service.Name = desiredService.Name
-		service.Spec = desiredService.Spec
-		if err := r.Update(ctx, &service); err != nil {
-			r.Logger.Error(err, "failed to update prometheus's Service")
-			return err
-		}
-	}
-
-	/** ServiceMonitor **/
-	var serviceMonitor pomonitoringv1.ServiceMonitor
-	desiredServiceMonitor := prometheus.ServiceMonitor(cell)
-	err = r.Get(ctx, types.NamespacedName{Name: desiredServiceMonitor.Name, Namespace: cell.Namespace}, &serviceMonitor)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child servicemonitor")
-		return err
-	}
-
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredServiceMonitor); err != nil {
-			r.Logger.Error(err, "failed to create prometheus's ServiceMonitor")
-			return err
-		}
-	} else {
-		serviceMonitor.Labels = desiredServiceMonitor.Labels
-		serviceMonitor.Name = desiredServiceMonitor.Name
-		serviceMonitor.Spec = desiredServiceMonitor.Spec
-		if err := r.Update(ctx, &serviceMonitor); err != nil {
-			r.Logger.Error(err, "failed to update prometheus's ServiceMonitor")
-			return err
-		}
-	}
-
-	/** Prometheus **/
-	// We can't name the variable 'prometheus' because it conflicts with the package named 'prometheus'
-	var p pomonitoringv1.Prometheus
-	desiredPrometheus := prometheus.Prometheus(cell)
-	err = r.Get(ctx, types.NamespacedName{Name: desiredPrometheus.Name, Namespace: cell.Namespace}, &p)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child prometheus")
-		return err
-	}
-
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredPrometheus); err != nil {
-			r.Logger.Error(err, "failed to create prometheus instance")
-			return err
-		}
-	} else {
-		p.Labels = desiredPrometheus.Labels
-		p.Name = desiredPrometheus.Name
-		p.Spec = desiredPrometheus.Spec
-		if err := r.Update(ctx, &p); err != nil {
-			r.Logger.Error(err, "failed to update prometheus instance")
-			return err
-		}
-	}
-
-	return nil
+	return r.reconcileObjects(ctx, cell, "prometheus", objects)
 }
 
-func (r *CellReconciler) isPrometheusReady(ctx context.Context, cell *monitoringv1alpha1.Cell) (bool, error) {
+func (r *CellReconciler) isPrometheusReady(ctx context.Context, cell *monitoringv1beta1.Cell) (bool, error) {
 	var p pomonitoringv1.Prometheus
 	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", prometheus.Name, cell.Name), Namespace: cell.Namespace}, &p)
 	if client.IgnoreNotFound(err) != nil {
@@ -669,288 +559,22 @@ func (r *CellReconciler) isPrometheusReady(ctx context.Context, cell *monitoring
 	return true, nil
 }
 
-func (r *CellReconciler) reconcileGitpodMonitoring(ctx context.Context, cell *monitoringv1alpha1.Cell, req ctrl.Request) error {
-	/** NetworkPolicies **/
-	desirednps := gitpod.NetworkPolicies(cell)
-	var currentnp networkv1.NetworkPolicy
-	for _, np := range desirednps {
-		err := r.Get(ctx, types.NamespacedName{Name: np.Name, Namespace: np.Namespace}, &currentnp)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child NetworkPolicy")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, np); err != nil {
-				r.Logger.Error(err, "failed to create NetworkPolicy")
-				return err
-			}
-		} else {
-			currentnp.Labels = np.Labels
-			currentnp.Name = np.Name
-			currentnp.Spec = np.Spec
-			if err := r.Update(ctx, &currentnp); err != nil {
-				r.Logger.Error(err, "failed to update NetworkPolicy")
-				return err
-			}
-		}
-	}
-
-	/** Services **/
-	desiredservices := gitpod.Services(cell)
-	var currentService corev1.Service
-	for _, svc := range desiredservices {
-		err := r.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace}, &currentService)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child Service")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, svc); err != nil {
-				r.Logger.Error(err, "failed to create Service")
-				return err
-			}
-		} else {
-			currentService.Labels = svc.Labels
-			currentService.Name = svc.Name
-			currentService.Spec = svc.Spec
-			if err := r.Update(ctx, &currentService); err != nil {
-				r.Logger.Error(err, "failed to update Service")
-				return err
-			}
-		}
-	}
-
-	/** ServicesMonitors **/
-	desiredServiceMonitors := gitpod.ServiceMonitors(cell)
-	var currentServiceMonitor pomonitoringv1.ServiceMonitor
-	for _, servicemonitor := range desiredServiceMonitors {
-		err := r.Get(ctx, types.NamespacedName{Name: servicemonitor.Name, Namespace: servicemonitor.Namespace}, &currentServiceMonitor)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child ServiceMonitor")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, servicemonitor); err != nil {
-				r.Logger.Error(err, "failed to create ServiceMonitor")
-				return err
-			}
-		} else {
-			currentServiceMonitor.Labels = servicemonitor.Labels
-			currentServiceMonitor.Name = servicemonitor.Name
-			currentServiceMonitor.Spec = servicemonitor.Spec
-			if err := r.Update(ctx, &currentServiceMonitor); err != nil {
-				r.Logger.Error(err, "failed to update ServiceMonitor")
-				return err
-			}
-		}
-	}
-
-	return nil
+func (r *CellReconciler) reconcileGitpodMonitoring(ctx context.Context, cell *monitoringv1beta1.Cell, req ctrl.Request) error {
+	return r.reconcileObjects(ctx, cell, "gitpod", components.GitpodMonitoring(cell))
 }
 
-func (r *CellReconciler) reconcileExporters(ctx context.Context, cell *monitoringv1alpha1.Cell, req ctrl.Request) error {
-	/** Cluster Roles **/
-	var desiredClusterRoles []*rbacv1.ClusterRole
-	desiredClusterRoles = append(desiredClusterRoles,
-		nodeexporter.ClusterRole(cell),
-		kubestatemetrics.ClusterRole(cell),
-	)
-	var currentClusterRole rbacv1.ClusterRole
-	for _, clusterRole := range desiredClusterRoles {
-		err := r.Get(ctx, types.NamespacedName{Name: clusterRole.Name, Namespace: clusterRole.Namespace}, &currentClusterRole)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child clusterrole")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, clusterRole); err != nil {
-				r.Logger.Error(err, "failed to create exporter ClusterRole")
-				return err
-			}
-		} else {
-			currentClusterRole.Labels = clusterRole.Labels
-			currentClusterRole.Name = clusterRole.Name
-			currentClusterRole.Rules = clusterRole.Rules
-			if err := r.Update(ctx, &currentClusterRole); err != nil {
-				r.Logger.Error(err, "failed to update exporter ClusterRole")
-				return err
-			}
-		}
-	}
-
-	/** ClusterRoleBinding **/
-	var desiredClusterRoleBindings []*rbacv1.ClusterRoleBinding
-	desiredClusterRoleBindings = append(desiredClusterRoleBindings,
-		nodeexporter.ClusterRoleBinding(cell),
-		kubestatemetrics.ClusterRoleBinding(cell),
-	)
-	var currentClusterRoleBinding rbacv1.ClusterRoleBinding
-	for _, clusterRoleBinding := range desiredClusterRoleBindings {
-		err := r.Get(ctx, types.NamespacedName{Name: clusterRoleBinding.Name, Namespace: clusterRoleBinding.Namespace}, &currentClusterRoleBinding)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child clusterrolebinding")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, clusterRoleBinding); err != nil {
-				r.Logger.Error(err, "failed to create exporter ClusterRoleBinding")
-				return err
-			}
-		} else {
-			currentClusterRoleBinding.Labels = clusterRoleBinding.Labels
-			currentClusterRoleBinding.Name = clusterRoleBinding.Name
-			currentClusterRoleBinding.Subjects = clusterRoleBinding.Subjects
-			currentClusterRoleBinding.RoleRef = clusterRoleBinding.RoleRef
-			if err := r.Update(ctx, &currentClusterRoleBinding); err != nil {
-				r.Logger.Error(err, "failed to update exporter ClusterRoleBinding")
-				return err
-			}
-		}
-	}
-
-	/** ServiceAccount **/
-	var desiredServiceAccounts []*corev1.ServiceAccount
-	desiredServiceAccounts = append(desiredServiceAccounts,
-		nodeexporter.ServiceAccount(cell),
-		kubestatemetrics.ServiceAccount(cell),
-	)
-	var currentServiceAccount corev1.ServiceAccount
-	for _, serviceAccount := range desiredServiceAccounts {
-		err := r.Get(ctx, types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, &currentServiceAccount)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child serviceaccount")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, serviceAccount); err != nil {
-				r.Logger.Error(err, "failed to create exporter ServiceAccount")
-				return err
-			}
-		} else {
-			currentServiceAccount.Labels = serviceAccount.Labels
-			currentServiceAccount.Name = serviceAccount.Name
-			currentServiceAccount.AutomountServiceAccountToken = serviceAccount.AutomountServiceAccountToken
-			if err := r.Update(ctx, &currentServiceAccount); err != nil {
-				r.Logger.Error(err, "failed to update exporter ServiceAccount")
-				return err
-			}
-		}
-	}
-
-	/** Service **/
-	var desiredServices []*corev1.Service
-	desiredServices = append(desiredServices,
-		nodeexporter.Service(cell),
-		kubestatemetrics.Service(cell),
-	)
-	var currentService corev1.Service
-	for _, service := range desiredServices {
-		err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, &currentService)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child service")
-			return err
-		}
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, service); err != nil {
-				r.Logger.Error(err, "failed to create exporter Service")
-				return err
-			}
-		} else {
-			currentService.Labels = service.Labels
-			currentService.Name = service.Name
-			currentService.Spec = service.Spec
-			if err := r.Update(ctx, &currentService); err != nil {
-				r.Logger.Error(err, "failed to update exporter Service")
-				return err
-			}
-		}
-	}
-
-	/** Daemonset **/
-	var daemonset appsv1.DaemonSet
-	desiredDaemonset := nodeexporter.Daemonset(cell)
-	err := r.Get(ctx, types.NamespacedName{Name: desiredDaemonset.Name, Namespace: desiredDaemonset.Namespace}, &daemonset)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child deployment")
-		return err
-	}
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredDaemonset); err != nil {
-			r.Logger.Error(err, "failed to create node-exporter's Daemonset")
-			return err
-		}
-	} else {
-		daemonset.Labels = desiredDaemonset.Labels
-		daemonset.Name = desiredDaemonset.Name
-		daemonset.Spec = desiredDaemonset.Spec
-		if err := r.Update(ctx, &daemonset); err != nil {
-			r.Logger.Error(err, "failed to update node-exporter's Daemonset")
-			return err
-		}
-	}
-
-	/** Deployment **/
-	var deployment appsv1.Deployment
-	desiredDeployment := kubestatemetrics.Deployment(cell)
-	err = r.Get(ctx, types.NamespacedName{Name: desiredDeployment.Name, Namespace: desiredDeployment.Namespace}, &deployment)
-	if client.IgnoreNotFound(err) != nil {
-		r.Logger.Error(err, "unable to get child deployment")
-		return err
-	}
-	if err != nil && apierrors.IsNotFound(err) {
-		if err := r.Create(ctx, desiredDeployment); err != nil {
-			r.Logger.Error(err, "failed to create kubestate-metrics's Deployment")
-			return err
-		}
-	} else {
-		deployment.Labels = desiredDeployment.Labels
-		deployment.Name = desiredDeployment.Name
-		deployment.Spec = desiredDeployment.Spec
-		if err := r.Update(ctx, &daemonset); err != nil {
-			r.Logger.Error(err, "failed to update kubestate-metrics's Daemonset")
-			return err
-		}
-	}
-
-	/** ServiceMonitor **/
-	var desiredServiceMonitors []*pomonitoringv1.ServiceMonitor
-	desiredServiceMonitors = append(desiredServiceMonitors, kubernetes.ServiceMonitors(cell)...)
-	desiredServiceMonitors = append(desiredServiceMonitors,
-		nodeexporter.ServiceMonitor(cell),
-		kubestatemetrics.ServiceMonitor(cell),
-	)
-
-	var currentServiceMonitor pomonitoringv1.ServiceMonitor
-	for _, servicemonitor := range desiredServiceMonitors {
-		err = r.Get(ctx, types.NamespacedName{Name: servicemonitor.Name, Namespace: servicemonitor.Namespace}, &currentServiceMonitor)
-		if client.IgnoreNotFound(err) != nil {
-			r.Logger.Error(err, "unable to get child servicemonitor")
-			return err
-		}
-
-		if err != nil && apierrors.IsNotFound(err) {
-			if err := r.Create(ctx, servicemonitor); err != nil {
-				r.Logger.Error(err, "failed to create exporter ServiceMonitor")
-				return err
-			}
-		} else {
-			currentServiceMonitor.Labels = servicemonitor.Labels
-			currentServiceMonitor.Name = servicemonitor.Name
-			currentServiceMonitor.Spec = servicemonitor.Spec
-			if err := r.Update(ctx, &currentServiceMonitor); err != nil {
-				r.Logger.Error(err, "failed to update exporter ServiceMonitor")
-				return err
-			}
-		}
-	}
-
-	return nil
+func (r *CellReconciler) reconcileExporters(ctx context.Context, cell *monitoringv1beta1.Cell, req ctrl.Request) error {
+	return r.reconcileObjects(ctx, cell, "exporters", components.Exporters(cell))
 }
 
-func (r *CellReconciler) isExporterReady(ctx context.Context, cell *monitoringv1alpha1.Cell, query string, expectedResult int) (bool, error) {
+func (r *CellReconciler) isExporterReady(ctx context.Context, cell *monitoringv1beta1.Cell, query string, expectedResult int) (bool, error) {
 
+	timer := prom.NewTimer(prometheusQueryDuration.WithLabelValues(cell.Namespace, cell.Name))
 	rsp, err := prometheus.Query(query, cell, r.PodRESTClient)
+	timer.ObserveDuration()
 	if err != nil {
+		prometheusQueryErrors.WithLabelValues(cell.Namespace, cell.Name).Inc()
+		r.Recorder.Eventf(cell, corev1.EventTypeWarning, "QueryFailed", "Querying Prometheus for %q failed: %v", query, err)
 		return false, err
 	}
 
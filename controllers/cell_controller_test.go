@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components"
+	nodeexporter "github.com/gitpod-io/monitoring-cell/pkg/components/node-exporter"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/prometheus"
+	prometheusoperator "github.com/gitpod-io/monitoring-cell/pkg/components/prometheus-operator"
+)
+
+var _ = Describe("Cell controller", Label("envtest"), func() {
+	var (
+		ctx           = context.Background()
+		reconciler    *CellReconciler
+		prometheusAPI *httptest.Server
+	)
+
+	BeforeEach(func() {
+		requireEnvtest()
+
+		prometheusAPI = httptest.NewServer(http.HandlerFunc(fakePrometheusQuery))
+		podRESTClient, err := rest.RESTClientFor(&rest.Config{
+			Host:    prometheusAPI.URL,
+			APIPath: "/api",
+			ContentConfig: rest.ContentConfig{
+				GroupVersion:         &corev1.SchemeGroupVersion,
+				NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		reconciler = &CellReconciler{
+			Client:        k8sClient,
+			PodRESTClient: podRESTClient,
+			Scheme:        scheme.Scheme,
+			Recorder:      &record.FakeRecorder{},
+		}
+	})
+
+	AfterEach(func() {
+		if prometheusAPI != nil {
+			prometheusAPI.Close()
+		}
+	})
+
+	reconcile := func(cell *monitoringv1beta1.Cell) {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cell)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cell), cell)).To(Succeed())
+	}
+
+	It("holds back prometheus-operator resources until the operator is ready", func() {
+		cell := createCell(ctx, "held-back")
+
+		reconcile(cell)
+		for _, obj := range components.Objects(cell) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj.DeepCopyObject().(client.Object))
+			if requiresOperator(obj) {
+				Expect(apierrors.IsNotFound(err)).To(BeTrue(), "%T %s shouldn't exist yet", obj, obj.GetName())
+			} else {
+				Expect(err).NotTo(HaveOccurred(), "%T %s should exist", obj, obj.GetName())
+			}
+		}
+
+		markOperatorReady(ctx, cell)
+		reconcile(cell)
+		for _, obj := range components.Objects(cell) {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj.DeepCopyObject().(client.Object))).
+				To(Succeed(), "%T %s should exist", obj, obj.GetName())
+		}
+	})
+
+	It("converges changes to the Cell and drift of its objects while everything is ready", func() {
+		cell := createCell(ctx, "converged")
+		reconcile(cell)
+		markOperatorReady(ctx, cell)
+		reconcile(cell)
+		markPrometheusReady(ctx, cell)
+		reconcile(cell)
+		Expect(meta.IsStatusConditionTrue(cell.Status.Conditions, monitoringv1beta1.Ready)).To(BeTrue())
+
+		cell.Spec.ClusterName = "changed"
+		Expect(k8sClient.Update(ctx, cell)).To(Succeed())
+		var deployment appsv1.Deployment
+		Expect(k8sClient.Get(ctx, operatorKey(cell), &deployment)).To(Succeed())
+		deployment.Spec.Replicas = pointer.Int32(0)
+		Expect(k8sClient.Update(ctx, &deployment)).To(Succeed())
+
+		reconcile(cell)
+
+		var p pomonitoringv1.Prometheus
+		Expect(k8sClient.Get(ctx, prometheusKey(cell), &p)).To(Succeed())
+		Expect(p.Spec.ExternalLabels).To(HaveKeyWithValue("cluster", "changed"))
+		Expect(k8sClient.Get(ctx, operatorKey(cell), &deployment)).To(Succeed())
+		Expect(deployment.Spec.Replicas).To(Equal(pointer.Int32(1)))
+	})
+
+	It("rolls out a change to a workload while other workloads are still rolling out", func() {
+		cell := createCell(ctx, "rollouts")
+		reconcile(cell)
+		markOperatorReady(ctx, cell)
+		reconcile(cell)
+		markPrometheusReady(ctx, cell)
+		reconcile(cell)
+		Expect(findRollout(cell, pomonitoringv1.PrometheusesKind, prometheusKey(cell).Name).Phase).
+			To(Equal(monitoringv1beta1.RolloutPhaseComplete))
+		// Nothing runs the node-exporter pods in envtest, so its rollout never finishes.
+		Expect(progressingRollout(cell)).NotTo(BeNil())
+
+		cell.Spec.ClusterName = "changed"
+		Expect(k8sClient.Update(ctx, cell)).To(Succeed())
+		reconcile(cell)
+
+		var p pomonitoringv1.Prometheus
+		Expect(k8sClient.Get(ctx, prometheusKey(cell), &p)).To(Succeed())
+		Expect(p.Spec.ExternalLabels).To(HaveKeyWithValue("cluster", "changed"))
+		Expect(findRollout(cell, pomonitoringv1.PrometheusesKind, prometheusKey(cell).Name).Phase).
+			To(Equal(monitoringv1beta1.RolloutPhaseProgressing))
+	})
+
+	It("reverts a workload that doesn't roll out within the deadline to its last known-good spec", func() {
+		cell := createCell(ctx, "rollback")
+		reconcile(cell)
+		markOperatorReady(ctx, cell)
+		reconcile(cell)
+		markPrometheusReady(ctx, cell)
+		reconcile(cell)
+
+		cell.Spec.ClusterName = "broken"
+		cell.Spec.RolloutDeadline = &metav1.Duration{Duration: time.Nanosecond}
+		Expect(k8sClient.Update(ctx, cell)).To(Succeed())
+		reconcile(cell)
+
+		rollout := findRollout(cell, pomonitoringv1.PrometheusesKind, prometheusKey(cell).Name)
+		Expect(rollout.Phase).To(Equal(monitoringv1beta1.RolloutPhaseFailed))
+		Expect(meta.IsStatusConditionTrue(cell.Status.Conditions, monitoringv1beta1.RolloutFailed)).To(BeTrue())
+		var p pomonitoringv1.Prometheus
+		Expect(k8sClient.Get(ctx, prometheusKey(cell), &p)).To(Succeed())
+		Expect(p.Spec.ExternalLabels).To(HaveKeyWithValue("cluster", "test"))
+
+		By("keeping the last known-good spec until the desired spec changes again")
+		reconcile(cell)
+		Expect(k8sClient.Get(ctx, prometheusKey(cell), &p)).To(Succeed())
+		Expect(p.Spec.ExternalLabels).To(HaveKeyWithValue("cluster", "test"))
+
+		cell.Spec.ClusterName = "fixed"
+		cell.Spec.RolloutDeadline = nil
+		Expect(k8sClient.Update(ctx, cell)).To(Succeed())
+		reconcile(cell)
+		Expect(k8sClient.Get(ctx, prometheusKey(cell), &p)).To(Succeed())
+		Expect(p.Spec.ExternalLabels).To(HaveKeyWithValue("cluster", "fixed"))
+		Expect(findRollout(cell, pomonitoringv1.PrometheusesKind, prometheusKey(cell).Name).Phase).
+			To(Equal(monitoringv1beta1.RolloutPhaseProgressing))
+	})
+
+	It("checks a cell that isn't ready again at growing intervals rather than immediately", func() {
+		cell := createCell(ctx, "backoff")
+
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cell)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Requeue).To(BeFalse())
+		Expect(result.RequeueAfter).To(BeNumerically(">=", notReadyBaseDelay))
+		first := result.RequeueAfter
+
+		result, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cell)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">=", 2*notReadyBaseDelay))
+		Expect(result.RequeueAfter).To(BeNumerically(">", first))
+	})
+
+	It("runs node-exporter per profile and deletes the DaemonSets of removed profiles", func() {
+		cell := createCell(ctx, "profiles")
+		cell.Spec.NodeExporter.Profiles = []monitoringv1beta1.NodeExporterProfile{
+			{Name: "workspace", NodeSelector: map[string]string{"gitpod.io/workload_workspace": "true"}},
+			{Name: "services", NodeSelector: map[string]string{"gitpod.io/workload_services": "true"}},
+		}
+		Expect(k8sClient.Update(ctx, cell)).To(Succeed())
+		reconcile(cell)
+
+		var daemonsets appsv1.DaemonSetList
+		Expect(k8sClient.List(ctx, &daemonsets, client.InNamespace(cell.Namespace), client.MatchingLabels(cell.Labels))).To(Succeed())
+		Expect(daemonsets.Items).To(HaveLen(2))
+		for _, ds := range daemonsets.Items {
+			Expect(ds.Spec.Template.Labels).To(HaveKey(nodeexporter.NodePoolLabel))
+			Expect(ds.Spec.Template.Spec.NodeSelector).To(HaveLen(1))
+		}
+
+		cell.Spec.NodeExporter.Profiles = cell.Spec.NodeExporter.Profiles[:1]
+		Expect(k8sClient.Update(ctx, cell)).To(Succeed())
+		reconcile(cell)
+
+		Expect(k8sClient.List(ctx, &daemonsets, client.InNamespace(cell.Namespace), client.MatchingLabels(cell.Labels))).To(Succeed())
+		Expect(daemonsets.Items).To(HaveLen(1))
+		Expect(daemonsets.Items[0].Name).To(HaveSuffix("-workspace"))
+	})
+})
+
+func createCell(ctx context.Context, name string) *monitoringv1beta1.Cell {
+	cell := &monitoringv1beta1.Cell{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"app.kubernetes.io/instance": name},
+		},
+		Spec: monitoringv1beta1.CellSpec{
+			ClusterName: "test",
+			Gitpod:      monitoringv1beta1.GitpodSpec{Namespace: "default"},
+		},
+	}
+	Expect(k8sClient.Create(ctx, cell)).To(Succeed())
+
+	return cell
+}
+
+func operatorKey(cell *monitoringv1beta1.Cell) types.NamespacedName {
+	return types.NamespacedName{Namespace: cell.Namespace, Name: fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name)}
+}
+
+func prometheusKey(cell *monitoringv1beta1.Cell) types.NamespacedName {
+	return types.NamespacedName{Namespace: cell.Namespace, Name: fmt.Sprintf("%s-%s", prometheus.Name, cell.Name)}
+}
+
+// markOperatorReady reports the prometheus-operator Deployment as rolled out and available, as no controllers
+// run in envtest.
+func markOperatorReady(ctx context.Context, cell *monitoringv1beta1.Cell) {
+	var deployment appsv1.Deployment
+	Expect(k8sClient.Get(ctx, operatorKey(cell), &deployment)).To(Succeed())
+	deployment.Status.ObservedGeneration = deployment.Generation
+	deployment.Status.Replicas = 1
+	deployment.Status.UpdatedReplicas = 1
+	deployment.Status.ReadyReplicas = 1
+	deployment.Status.AvailableReplicas = 1
+	deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:   appsv1.DeploymentAvailable,
+		Status: corev1.ConditionTrue,
+	}}
+	Expect(k8sClient.Status().Update(ctx, &deployment)).To(Succeed())
+}
+
+// markPrometheusReady reports the Prometheus as rolled out and available, as there's no prometheus-operator
+// running in envtest.
+func markPrometheusReady(ctx context.Context, cell *monitoringv1beta1.Cell) {
+	var p pomonitoringv1.Prometheus
+	Expect(k8sClient.Get(ctx, prometheusKey(cell), &p)).To(Succeed())
+	p.Status.Replicas = 1
+	p.Status.UpdatedReplicas = 1
+	p.Status.AvailableReplicas = 1
+	p.Status.Conditions = []pomonitoringv1.PrometheusCondition{{
+		Type:               pomonitoringv1.PrometheusAvailable,
+		Status:             pomonitoringv1.PrometheusConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		ObservedGeneration: p.Generation,
+	}}
+	Expect(k8sClient.Status().Update(ctx, &p)).To(Succeed())
+}
+
+var jobPattern = regexp.MustCompile(`job="([^"]+)"`)
+
+// fakePrometheusQuery answers the readiness queries of the controller as a Prometheus scraping all targets would.
+func fakePrometheusQuery(w http.ResponseWriter, r *http.Request) {
+	targets := map[string]int{"node-exporter": 1, "kube-state-metrics": 2, "kubelet": 3, "apiserver": 1}
+
+	n := 0
+	if m := jobPattern.FindStringSubmatch(r.URL.Query().Get("query")); m != nil {
+		n = targets[m[1]]
+	}
+	result := make([]map[string]interface{}, n)
+	for i := range result {
+		result[i] = map[string]interface{}{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": "vector", "result": result},
+	})
+}
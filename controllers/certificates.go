@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+	nodeexporter "github.com/gitpod-io/monitoring-cell/pkg/components/node-exporter"
+	prometheusoperator "github.com/gitpod-io/monitoring-cell/pkg/components/prometheus-operator"
+	"github.com/gitpod-io/monitoring-cell/pkg/pki"
+)
+
+const (
+	caValidity          = 5 * 365 * 24 * time.Hour
+	certificateValidity = 90 * 24 * time.Hour
+	// certificateRenewBefore leaves the periodic resync of the cell plenty of chances to rotate a certificate
+	certificateRenewBefore = 30 * 24 * time.Hour
+
+	// trustBundleKey holds the CA certificates a certificate is verified with, in the Secrets of the CA and
+	// of every certificate
+	trustBundleKey = "ca.crt"
+)
+
+// caSecretName is the Secret holding the cell's CA and its private key
+func caSecretName(cell *monitoringv1beta1.Cell) string {
+	return cell.Name + "-ca"
+}
+
+// servingCertificates are the certificates the kube-rbac-proxy sidecars of the cell's components serve with.
+func servingCertificates(cell *monitoringv1beta1.Cell) []pki.Certificate {
+	return []pki.Certificate{
+		prometheusoperator.ServingCertificate(cell),
+		nodeexporter.ServingCertificate(cell),
+		kubestatemetrics.ServingCertificate(cell),
+	}
+}
+
+// reconcileCertificates issues the serving certificates of the cell's components with the cell's CA, creating
+// the CA first if needed, and reports their expiry in the status. Certificates are reissued when they're
+// about to expire, their DNS names change, or the CA is replaced. The CA is replaced once it would expire
+// before a certificate issued now. The trust bundle distributed with each certificate keeps the replaced CA
+// until it expires, so clients trust certificates issued by either while they're reissued.
+//
+// Only scrapes that verify TLS use the certificates, so nothing is issued otherwise. Secrets issued before
+// are left in place, in case verifying is turned back on.
+func (r *CellReconciler) reconcileCertificates(ctx context.Context, cell *monitoringv1beta1.Cell) error {
+	if cell.Spec.ScrapeTLS.Mode != monitoringv1beta1.ScrapeTLSVerify {
+		cell.Status.Certificates = nil
+		return nil
+	}
+
+	now := time.Now()
+
+	ca, trust, err := r.getKeyPair(ctx, cell, caSecretName(cell))
+	if err != nil {
+		return err
+	}
+	if ca == nil || pki.Verify(ca, ca.Cert, nil, now, certificateValidity) != nil {
+		replaced := ca != nil
+		if ca, err = pki.NewCA(cell.Namespace+"/"+cell.Name, now, caValidity); err != nil {
+			return err
+		}
+		if replaced {
+			r.Recorder.Eventf(cell, corev1.EventTypeNormal, "CARotated", "Replaced CA %s, reissuing all certificates", caSecretName(cell))
+		}
+	}
+	if bundle := pki.TrustBundle(now, ca.Cert, trust); !bytes.Equal(bundle, trust) {
+		trust = bundle
+		if err := r.writeKeyPair(ctx, cell, caSecretName(cell), ca, trust); err != nil {
+			return err
+		}
+	}
+	statuses := []monitoringv1beta1.CertificateStatus{certificateStatus(caSecretName(cell), nil, ca, certificateValidity)}
+
+	for _, certificate := range servingCertificates(cell) {
+		current, currentTrust, err := r.getKeyPair(ctx, cell, certificate.SecretName)
+		if err != nil {
+			return err
+		}
+		if current == nil || pki.Verify(current, ca.Cert, certificate.DNSNames, now, certificateRenewBefore) != nil {
+			if current, err = pki.Issue(ca, certificate.DNSNames, now, certificateValidity); err != nil {
+				return err
+			}
+			r.Recorder.Eventf(cell, corev1.EventTypeNormal, "CertificateIssued", "Issued certificate %s for %v", certificate.SecretName, certificate.DNSNames)
+			currentTrust = nil
+		}
+		if !bytes.Equal(currentTrust, trust) {
+			if err := r.writeKeyPair(ctx, cell, certificate.SecretName, current, trust); err != nil {
+				return err
+			}
+		}
+		statuses = append(statuses, certificateStatus(certificate.SecretName, certificate.DNSNames, current, certificateRenewBefore))
+	}
+	cell.Status.Certificates = statuses
+
+	return nil
+}
+
+// certificateStatus reports when a certificate expires, and when it's renewed before that.
+func certificateStatus(secretName string, dnsNames []string, pair *pki.KeyPair, renewBefore time.Duration) monitoringv1beta1.CertificateStatus {
+	status := monitoringv1beta1.CertificateStatus{SecretName: secretName, DNSNames: dnsNames}
+	// Only valid key pairs get here, so the certificate parses
+	if cert, err := pki.ParseCertificate(pair.Cert); err == nil {
+		status.NotAfter = metav1.NewTime(cert.NotAfter)
+		status.RenewAfter = metav1.NewTime(cert.NotAfter.Add(-renewBefore))
+	}
+
+	return status
+}
+
+// getKeyPair reads a certificate, its key and the trust bundle from a TLS Secret of the cell. It returns nil
+// if the Secret doesn't exist.
+func (r *CellReconciler) getKeyPair(ctx context.Context, cell *monitoringv1beta1.Cell, name string) (*pki.KeyPair, []byte, error) {
+	secret := &corev1.Secret{}
+	if err := r.reader().Get(ctx, types.NamespacedName{Namespace: cell.Namespace, Name: name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	return &pki.KeyPair{Cert: secret.Data[corev1.TLSCertKey], Key: secret.Data[corev1.TLSPrivateKeyKey]}, secret.Data[trustBundleKey], nil
+}
+
+// writeKeyPair creates or updates a TLS Secret of the cell, along with the trust bundle it's verified with. An
+// existing Secret is updated from the version read, so concurrent changes to it conflict instead of being
+// overwritten.
+func (r *CellReconciler) writeKeyPair(ctx context.Context, cell *monitoringv1beta1.Cell, name string, pair *pki.KeyPair, trust []byte) error {
+	secret := &corev1.Secret{}
+	err := r.reader().Get(ctx, types.NamespacedName{Namespace: cell.Namespace, Name: name}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cell.Namespace},
+			Type:       corev1.SecretTypeTLS,
+		}
+		setKeyPair(cell, secret, pair, trust)
+		err = r.Create(ctx, secret)
+	case err == nil:
+		setKeyPair(cell, secret, pair, trust)
+		err = r.Update(ctx, secret)
+	}
+	if err != nil {
+		r.Logger.Error(err, "failed to write certificate", "name", objectRef(cell.Namespace, name))
+		r.Recorder.Eventf(cell, corev1.EventTypeWarning, "CertificateWriteFailed", "Failed to write certificate %s: %v", name, err)
+	}
+
+	return err
+}
+
+// setKeyPair sets the certificate, its key and trust bundle on a Secret, along with the cell's labels and
+// ownership. Other keys of the Secret are kept.
+func setKeyPair(cell *monitoringv1beta1.Cell, secret *corev1.Secret, pair *pki.KeyPair, trust []byte) {
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	for k, v := range cell.Labels {
+		secret.Labels[k] = v
+	}
+	secret.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: cell.APIVersion,
+			Kind:       cell.Kind,
+			Name:       cell.Name,
+			UID:        cell.UID,
+		},
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[corev1.TLSCertKey] = pair.Cert
+	secret.Data[corev1.TLSPrivateKeyKey] = pair.Key
+	secret.Data[trustBundleKey] = trust
+}
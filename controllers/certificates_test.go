@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	nodeexporter "github.com/gitpod-io/monitoring-cell/pkg/components/node-exporter"
+	"github.com/gitpod-io/monitoring-cell/pkg/pki"
+)
+
+var _ = Describe("reconcileCertificates", func() {
+	var (
+		cell     *monitoringv1beta1.Cell
+		r        *CellReconciler
+		recorder *record.FakeRecorder
+		ctx      = context.Background()
+	)
+
+	secret := func(name string) *corev1.Secret {
+		s := &corev1.Secret{}
+		Expect(r.Get(ctx, types.NamespacedName{Namespace: cell.Namespace, Name: name}, s)).To(Succeed())
+		return s
+	}
+	keyPair := func(name string) *pki.KeyPair {
+		s := secret(name)
+		return &pki.KeyPair{Cert: s.Data[corev1.TLSCertKey], Key: s.Data[corev1.TLSPrivateKeyKey]}
+	}
+	// overwrite replaces the key pair of a Secret, as if it had been written at some point in the past
+	overwrite := func(name string, pair *pki.KeyPair, trust []byte) {
+		s := secret(name)
+		s.Data = map[string][]byte{corev1.TLSCertKey: pair.Cert, corev1.TLSPrivateKeyKey: pair.Key, trustBundleKey: trust}
+		Expect(r.Update(ctx, s)).To(Succeed())
+	}
+	events := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		return events
+	}
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{
+			TypeMeta:   metav1.TypeMeta{APIVersion: monitoringv1beta1.GroupVersion.String(), Kind: "Cell"},
+			ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "monitoring", UID: "9a7e", Labels: map[string]string{"team": "platform"}},
+		}
+		cell.Spec.ScrapeTLS.Mode = monitoringv1beta1.ScrapeTLSVerify
+		recorder = record.NewFakeRecorder(100)
+		r = &CellReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+			Logger:   logr.Discard(),
+			Recorder: recorder,
+		}
+	})
+
+	It("issues nothing unless scrapes verify TLS", func() {
+		cell.Spec.ScrapeTLS.Mode = monitoringv1beta1.ScrapeTLSInsecure
+		cell.Status.Certificates = []monitoringv1beta1.CertificateStatus{{SecretName: "cell-ca"}}
+		Expect(r.reconcileCertificates(ctx, cell)).To(Succeed())
+
+		secrets := &corev1.SecretList{}
+		Expect(r.List(ctx, secrets)).To(Succeed())
+		Expect(secrets.Items).To(BeEmpty())
+		Expect(cell.Status.Certificates).To(BeNil())
+	})
+
+	It("issues the certificates of the sidecars with a new CA, and leaves them be after", func() {
+		Expect(r.reconcileCertificates(ctx, cell)).To(Succeed())
+
+		ca := keyPair(caSecretName(cell))
+		var names []string
+		for _, certificate := range servingCertificates(cell) {
+			Expect(pki.Verify(keyPair(certificate.SecretName), ca.Cert, certificate.DNSNames, time.Now(), certificateRenewBefore)).To(Succeed())
+			Expect(secret(certificate.SecretName).Data[trustBundleKey]).To(Equal(ca.Cert))
+			Expect(secret(certificate.SecretName).Labels).To(HaveKeyWithValue("team", "platform"))
+			Expect(secret(certificate.SecretName).OwnerReferences).To(ConsistOf(HaveField("UID", cell.UID)))
+			names = append(names, certificate.SecretName)
+		}
+		Expect(names).NotTo(ContainElement(ContainSubstring("prometheus-cell")))
+		Expect(cell.Status.Certificates).To(HaveLen(4))
+		Expect(events()).To(HaveLen(3))
+
+		versions := map[string]string{}
+		for _, name := range append(names, caSecretName(cell)) {
+			versions[name] = secret(name).ResourceVersion
+		}
+		Expect(r.reconcileCertificates(ctx, cell)).To(Succeed())
+		for name, version := range versions {
+			Expect(secret(name).ResourceVersion).To(Equal(version), name)
+		}
+		Expect(events()).To(BeEmpty())
+	})
+
+	Context("with certificates issued before", func() {
+		var ca *pki.KeyPair
+		certificate := func() pki.Certificate { return nodeexporter.ServingCertificate(cell) }
+
+		BeforeEach(func() {
+			Expect(r.reconcileCertificates(ctx, cell)).To(Succeed())
+			ca = keyPair(caSecretName(cell))
+			events()
+		})
+
+		It("reissues a certificate that's about to expire", func() {
+			old, err := pki.Issue(ca, certificate().DNSNames, time.Now().Add(-80*24*time.Hour), certificateValidity)
+			Expect(err).NotTo(HaveOccurred())
+			overwrite(certificate().SecretName, old, ca.Cert)
+
+			Expect(r.reconcileCertificates(ctx, cell)).To(Succeed())
+			current := keyPair(certificate().SecretName)
+			Expect(current.Cert).NotTo(Equal(old.Cert))
+			Expect(pki.Verify(current, ca.Cert, certificate().DNSNames, time.Now(), certificateRenewBefore)).To(Succeed())
+			Expect(events()).To(ConsistOf(HavePrefix("Normal CertificateIssued Issued certificate " + certificate().SecretName)))
+		})
+
+		It("reissues a certificate when its DNS names change", func() {
+			old, err := pki.Issue(ca, []string{"node-exporter.other.svc"}, time.Now(), certificateValidity)
+			Expect(err).NotTo(HaveOccurred())
+			overwrite(certificate().SecretName, old, ca.Cert)
+
+			Expect(r.reconcileCertificates(ctx, cell)).To(Succeed())
+			current := keyPair(certificate().SecretName)
+			Expect(pki.Verify(current, ca.Cert, certificate().DNSNames, time.Now(), certificateRenewBefore)).To(Succeed())
+			Expect(events()).To(ConsistOf(HavePrefix("Normal CertificateIssued Issued certificate " + certificate().SecretName)))
+		})
+
+		It("replaces a CA that's about to expire, trusting both while certificates are reissued", func() {
+			old, err := pki.NewCA("monitoring/cell", time.Now().Add(-caValidity+60*24*time.Hour), caValidity)
+			Expect(err).NotTo(HaveOccurred())
+			overwrite(caSecretName(cell), old, old.Cert)
+
+			Expect(r.reconcileCertificates(ctx, cell)).To(Succeed())
+			current := keyPair(caSecretName(cell))
+			Expect(current.Cert).NotTo(Equal(old.Cert))
+			bundle := secret(caSecretName(cell)).Data[trustBundleKey]
+			Expect(bundle).To(Equal(append(append([]byte{}, current.Cert...), old.Cert...)))
+
+			for _, certificate := range servingCertificates(cell) {
+				Expect(pki.Verify(keyPair(certificate.SecretName), current.Cert, certificate.DNSNames, time.Now(), certificateRenewBefore)).To(Succeed())
+				Expect(secret(certificate.SecretName).Data[trustBundleKey]).To(Equal(bundle))
+			}
+			Expect(events()).To(ContainElement(HavePrefix("Normal CARotated")))
+		})
+
+		It("updates the Secrets it reads, keeping what else is in them", func() {
+			s := secret(certificate().SecretName)
+			s.Labels["keep"] = "label"
+			s.Data["keep"] = []byte("data")
+			s.Data[corev1.TLSCertKey] = nil
+			Expect(r.Update(ctx, s)).To(Succeed())
+
+			Expect(r.reconcileCertificates(ctx, cell)).To(Succeed())
+			s = secret(certificate().SecretName)
+			Expect(s.Labels).To(HaveKeyWithValue("keep", "label"))
+			Expect(s.Data).To(HaveKeyWithValue("keep", []byte("data")))
+			Expect(s.Data[corev1.TLSCertKey]).NotTo(BeEmpty())
+		})
+	})
+
+	It("doesn't overwrite a Secret changed since it was read", func() {
+		inner := r.Client
+		Expect(inner.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: caSecretName(cell), Namespace: cell.Namespace},
+			Data:       map[string][]byte{},
+		})).To(Succeed())
+		// The Secret changes between reading and updating it
+		r.Client = interceptUpdate{Client: inner, before: func(obj client.Object) {
+			changed := &corev1.Secret{}
+			Expect(inner.Get(ctx, client.ObjectKeyFromObject(obj), changed)).To(Succeed())
+			changed.Data = map[string][]byte{"concurrent": []byte("change")}
+			Expect(inner.Update(ctx, changed)).To(Succeed())
+		}}
+
+		Expect(apierrors.IsConflict(r.reconcileCertificates(ctx, cell))).To(BeTrue())
+		Expect(events()).To(ContainElement(HavePrefix("Warning CertificateWriteFailed")))
+	})
+})
+
+// interceptUpdate calls before ahead of every update through the client.
+type interceptUpdate struct {
+	client.Client
+	before func(client.Object)
+}
+
+func (c interceptUpdate) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.before(obj)
+	return c.Client.Update(ctx, obj, opts...)
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+// readyCondition summarises the readiness reported in the cell status into the Ready condition.
+func readyCondition(cell *monitoringv1beta1.Cell, now time.Time) metav1.Condition {
+	if isPaused(cell, now) {
+		msg := "reconciliation is paused"
+		if cell.Spec.PausedUntil != nil {
+			msg += " until " + cell.Spec.PausedUntil.UTC().Format(time.RFC3339)
+		}
+		return metav1.Condition{
+			Type:    monitoringv1beta1.Ready,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Paused",
+			Message: msg,
+		}
+	}
+
+	if notReady := notReadyComponents(&cell.Status); len(notReady) > 0 {
+		return metav1.Condition{
+			Type:    monitoringv1beta1.Ready,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ComponentsNotReady",
+			Message: "components not ready: " + strings.Join(notReady, ", "),
+		}
+	}
+
+	return metav1.Condition{
+		Type:    monitoringv1beta1.Ready,
+		Status:  metav1.ConditionTrue,
+		Reason:  "ComponentsReady",
+		Message: "all components are ready",
+	}
+}
+
+type componentReadiness struct {
+	name  string
+	ready *bool
+}
+
+func componentsReadiness(status *monitoringv1beta1.CellStatus) []componentReadiness {
+	return []componentReadiness{
+		{"prometheus-operator", status.PrometheusOperatorReady},
+		{"prometheus", status.PrometheusReady},
+		{"node-exporter", status.NodeExporterReady},
+		{"kube-state-metrics", status.KubeStateMetricsReady},
+		{"kubelet", status.KubeletReady},
+		{"apiserver", status.APIServerReady},
+	}
+}
+
+// notReadyComponents lists the components the cell status doesn't report as ready, including the ones
+// that haven't been checked yet.
+func notReadyComponents(status *monitoringv1beta1.CellStatus) []string {
+	var notReady []string
+	for _, c := range componentsReadiness(status) {
+		if c.ready == nil || !*c.ready {
+			notReady = append(notReady, c.name)
+		}
+	}
+
+	return notReady
+}
+
+// recordReadinessTransitions records an event for every component, and for the cell as a whole,
+// whose readiness changed since the previous status.
+func (r *CellReconciler) recordReadinessTransitions(cell *monitoringv1beta1.Cell, previous *monitoringv1beta1.CellStatus) {
+	before := componentsReadiness(previous)
+	for i, c := range componentsReadiness(&cell.Status) {
+		was := before[i].ready
+		switch {
+		case c.ready == nil:
+		case *c.ready && (was == nil || !*was):
+			r.Recorder.Eventf(cell, corev1.EventTypeNormal, "ComponentReady", "%s is ready", c.name)
+		case !*c.ready && was != nil && *was:
+			r.Recorder.Eventf(cell, corev1.EventTypeWarning, "ComponentNotReady", "%s is no longer ready", c.name)
+		}
+	}
+
+	ready := meta.FindStatusCondition(cell.Status.Conditions, monitoringv1beta1.Ready)
+	prev := meta.FindStatusCondition(previous.Conditions, monitoringv1beta1.Ready)
+	if ready == nil || (prev != nil && prev.Status == ready.Status && prev.Reason == ready.Reason) {
+		return
+	}
+	switch {
+	case ready.Status == metav1.ConditionTrue:
+		r.Recorder.Event(cell, corev1.EventTypeNormal, "Ready", ready.Message)
+	case ready.Reason == "Paused":
+		r.Recorder.Event(cell, corev1.EventTypeNormal, "Paused", ready.Message)
+	default:
+		r.Recorder.Event(cell, corev1.EventTypeWarning, "NotReady", ready.Message)
+	}
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"strings"
+
+	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// requiredCRD is a CustomResourceDefinition the controller depends on but doesn't install itself.
+type requiredCRD struct {
+	Name string
+	GVK  schema.GroupVersionKind
+}
+
+var requiredCRDs = []requiredCRD{
+	{Name: "prometheuses.monitoring.coreos.com", GVK: pomonitoringv1.SchemeGroupVersion.WithKind(pomonitoringv1.PrometheusesKind)},
+	{Name: "servicemonitors.monitoring.coreos.com", GVK: pomonitoringv1.SchemeGroupVersion.WithKind(pomonitoringv1.ServiceMonitorsKind)},
+	{Name: "prometheusrules.monitoring.coreos.com", GVK: pomonitoringv1.SchemeGroupVersion.WithKind(pomonitoringv1.PrometheusRuleKind)},
+}
+
+// missingCRDs returns the names of the required CRDs that aren't served by the API server.
+// The manager's RESTMapper reloads discovery information on a miss, so CRDs installed after
+// the operator started are picked up without a restart.
+func missingCRDs(mapper meta.RESTMapper) ([]string, error) {
+	var missing []string
+	for _, crd := range requiredCRDs {
+		_, err := mapper.RESTMapping(crd.GVK.GroupKind(), crd.GVK.Version)
+		if meta.IsNoMatchError(err) {
+			missing = append(missing, crd.Name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return missing, nil
+}
+
+func missingCRDsMessage(missing []string) string {
+	return "required CRDs are not installed: " + strings.Join(missing, ", ")
+}
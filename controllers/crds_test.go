@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("Required CRDs", func() {
+	// mapper serves the prometheus-operator kinds given
+	mapper := func(kinds ...string) *meta.DefaultRESTMapper {
+		m := meta.NewDefaultRESTMapper(nil)
+		for _, kind := range kinds {
+			m.Add(pomonitoringv1.SchemeGroupVersion.WithKind(kind), meta.RESTScopeNamespace)
+		}
+		return m
+	}
+
+	Describe("missingCRDs", func() {
+		It("finds none while all are served", func() {
+			Expect(missingCRDs(mapper(pomonitoringv1.PrometheusesKind, pomonitoringv1.ServiceMonitorsKind, pomonitoringv1.PrometheusRuleKind))).To(BeEmpty())
+		})
+
+		It("names the ones that aren't served", func() {
+			Expect(missingCRDs(mapper(pomonitoringv1.PrometheusesKind))).To(Equal([]string{
+				"servicemonitors.monitoring.coreos.com",
+				"prometheusrules.monitoring.coreos.com",
+			}))
+		})
+
+		It("fails when discovery fails", func() {
+			_, err := missingCRDs(failingMapper{mapper()})
+			Expect(err).To(MatchError("discovery failed"))
+		})
+	})
+
+	It("reports missing CRDs on the Cell and checks again later", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(monitoringv1beta1.AddToScheme(scheme)).To(Succeed())
+		cell := &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "monitoring"}}
+		recorder := record.NewFakeRecorder(10)
+		r := &CellReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRESTMapper(mapper(pomonitoringv1.PrometheusesKind, pomonitoringv1.PrometheusRuleKind)).
+				WithObjects(cell).
+				Build(),
+			Recorder: recorder,
+		}
+
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cell)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(time.Minute))
+
+		message := "required CRDs are not installed: servicemonitors.monitoring.coreos.com"
+		Expect(recorder.Events).To(Receive(Equal("Warning CRDsMissing " + message)))
+		Expect(r.Get(context.Background(), client.ObjectKeyFromObject(cell), cell)).To(Succeed())
+		Expect(meta.FindStatusCondition(cell.Status.Conditions, monitoringv1beta1.CRDsMissing)).To(haveCondition(metav1.ConditionTrue, "CRDsNotInstalled", message))
+		Expect(meta.FindStatusCondition(cell.Status.Conditions, monitoringv1beta1.Ready)).To(haveCondition(metav1.ConditionFalse, "CRDsMissing", message))
+	})
+})
+
+// haveCondition matches a condition by its status, reason and message.
+func haveCondition(status metav1.ConditionStatus, reason, message string) OmegaMatcher {
+	return And(HaveField("Status", status), HaveField("Reason", reason), HaveField("Message", message))
+}
+
+// failingMapper fails every lookup, as when discovery is unavailable.
+type failingMapper struct {
+	meta.RESTMapper
+}
+
+func (failingMapper) RESTMapping(schema.GroupKind, ...string) (*meta.RESTMapping, error) {
+	return nil, errors.New("discovery failed")
+}
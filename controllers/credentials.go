@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+// checkRemoteWriteCredentials verifies that the Secret keys referenced for remote-write credentials exist,
+// and records the outcome per endpoint in the cell status. A hash of the revisions of the Secrets is recorded
+// too, so Prometheus is restarted when they're rotated. It's derived from their UIDs and resource versions
+// rather than their content, which must not be exposed.
+func (r *CellReconciler) checkRemoteWriteCredentials(ctx context.Context, cell *monitoringv1beta1.Cell) error {
+	secrets := map[string]*corev1.Secret{}
+
+	var statuses []monitoringv1beta1.RemoteWriteStatus
+	var allMissing []string
+	for _, rw := range cell.Spec.Metrics.RemoteWrite {
+		status := monitoringv1beta1.RemoteWriteStatus{Name: remoteWriteName(rw), URL: rw.URL}
+		if previous := findRemoteWriteStatus(cell, status.Name); previous != nil {
+			status = *previous.DeepCopy()
+			status.URL = rw.URL
+		}
+
+		refs := credentialRefs(rw)
+		var missing []string
+		for _, ref := range refs {
+			secret, err := r.getSecret(ctx, cell.Namespace, ref.Name, secrets)
+			if err != nil {
+				return err
+			}
+			if _, ok := secret.Data[ref.Key]; !ok && (ref.Optional == nil || !*ref.Optional) {
+				missing = append(missing, ref.Name+"/"+ref.Key)
+			}
+		}
+
+		status.CredentialsPresent = nil
+		status.CredentialsMessage = ""
+		if len(refs) > 0 {
+			present := len(missing) == 0
+			status.CredentialsPresent = &present
+		}
+		if len(missing) > 0 {
+			status.CredentialsMessage = "missing Secret keys: " + strings.Join(missing, ", ")
+			allMissing = append(allMissing, missing...)
+		}
+		statuses = append(statuses, status)
+	}
+	cell.Status.RemoteWrite = statuses
+
+	cell.Status.CredentialsHash = credentialsHash(secrets)
+
+	if len(allMissing) == 0 {
+		meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+			Type:    monitoringv1beta1.CredentialsMissing,
+			Status:  metav1.ConditionFalse,
+			Reason:  "CredentialsPresent",
+			Message: "all referenced Secret keys exist",
+		})
+		return nil
+	}
+
+	msg := "remote-write credentials reference missing Secret keys: " + strings.Join(allMissing, ", ")
+	if !meta.IsStatusConditionTrue(cell.Status.Conditions, monitoringv1beta1.CredentialsMissing) {
+		r.Recorder.Event(cell, corev1.EventTypeWarning, "CredentialsMissing", msg)
+	}
+	meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+		Type:    monitoringv1beta1.CredentialsMissing,
+		Status:  metav1.ConditionTrue,
+		Reason:  "SecretKeysNotFound",
+		Message: msg,
+	})
+	return nil
+}
+
+// credentialsHash identifies the revisions of the Secrets that exist, or is empty if there are none.
+func credentialsHash(secrets map[string]*corev1.Secret) string {
+	var names []string
+	for name, secret := range secrets {
+		if secret.UID != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s/%s/%s\n", name, secrets[name].UID, secrets[name].ResourceVersion)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+// getSecret fetches a Secret once per reconcile. A Secret that doesn't exist is returned empty. Secrets
+// aren't cached, so they're read from the API server.
+func (r *CellReconciler) getSecret(ctx context.Context, namespace, name string, secrets map[string]*corev1.Secret) (*corev1.Secret, error) {
+	if secret, ok := secrets[name]; ok {
+		return secret, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.reader().Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+	secrets[name] = secret
+
+	return secret, nil
+}
+
+// credentialRefs lists the Secret keys a remote-write endpoint takes its credentials from.
+func credentialRefs(rw monitoringv1beta1.RemoteWriteSpec) []corev1.SecretKeySelector {
+	var refs []corev1.SecretKeySelector
+	if rw.BasicAuth != nil {
+		refs = append(refs, rw.BasicAuth.Username, rw.BasicAuth.Password)
+	}
+	if rw.Authorization != nil && rw.Authorization.Credentials != nil {
+		refs = append(refs, *rw.Authorization.Credentials)
+	}
+	if rw.OAuth2 != nil {
+		if rw.OAuth2.ClientID.Secret != nil {
+			refs = append(refs, *rw.OAuth2.ClientID.Secret)
+		}
+		refs = append(refs, rw.OAuth2.ClientSecret)
+	}
+	if rw.Sigv4 != nil {
+		if rw.Sigv4.AccessKey != nil {
+			refs = append(refs, *rw.Sigv4.AccessKey)
+		}
+		if rw.Sigv4.SecretKey != nil {
+			refs = append(refs, *rw.Sigv4.SecretKey)
+		}
+	}
+
+	var set []corev1.SecretKeySelector
+	for _, ref := range refs {
+		if ref.Name != "" {
+			set = append(set, ref)
+		}
+	}
+
+	return set
+}
+
+func remoteWriteName(rw monitoringv1beta1.RemoteWriteSpec) string {
+	if rw.Name != "" {
+		return rw.Name
+	}
+
+	return rw.URL
+}
+
+func findRemoteWriteStatus(cell *monitoringv1beta1.Cell, name string) *monitoringv1beta1.RemoteWriteStatus {
+	for i := range cell.Status.RemoteWrite {
+		if cell.Status.RemoteWrite[i].Name == name {
+			return &cell.Status.RemoteWrite[i]
+		}
+	}
+
+	return nil
+}
+
+// cellsForSecret maps a Secret to the cells in its namespace that take remote-write credentials from it.
+func (r *CellReconciler) cellsForSecret(obj client.Object) []reconcile.Request {
+	var cells monitoringv1beta1.CellList
+	if err := r.List(context.Background(), &cells, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Log.WithName(controllerName).Error(err, "Unable to list Cells referencing Secret", "secret", objectRef(obj.GetNamespace(), obj.GetName()))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range cells.Items {
+		cell := &cells.Items[i]
+		for _, rw := range cell.Spec.Metrics.RemoteWrite {
+			if referencesSecret(rw, obj.GetName()) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cell)})
+				break
+			}
+		}
+	}
+
+	return requests
+}
+
+func referencesSecret(rw monitoringv1beta1.RemoteWriteSpec, name string) bool {
+	for _, ref := range credentialRefs(rw) {
+		if ref.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Credentials hash", func() {
+	secret := func(resourceVersion, password string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "remote-write", UID: "8f1c", ResourceVersion: resourceVersion},
+			Data:       map[string][]byte{"password": []byte(password)},
+		}
+	}
+
+	It("changes with the revision of a Secret", func() {
+		Expect(credentialsHash(map[string]*corev1.Secret{"remote-write": secret("1", "a")})).
+			NotTo(Equal(credentialsHash(map[string]*corev1.Secret{"remote-write": secret("2", "a")})))
+	})
+
+	It("doesn't depend on the content of a Secret", func() {
+		Expect(credentialsHash(map[string]*corev1.Secret{"remote-write": secret("1", "a")})).
+			To(Equal(credentialsHash(map[string]*corev1.Secret{"remote-write": secret("1", "b")})))
+	})
+
+	It("is empty while none of the Secrets exist", func() {
+		Expect(credentialsHash(map[string]*corev1.Secret{"remote-write": {}})).To(BeEmpty())
+		Expect(credentialsHash(nil)).To(BeEmpty())
+	})
+})
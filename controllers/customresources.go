@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+)
+
+// resolveCustomResources looks up the resources of the cell's kube-state-metrics custom resource state config,
+// so it's only granted access to custom resources the API server serves, under the plural they're served as.
+func (r *CellReconciler) resolveCustomResources(cell *monitoringv1beta1.Cell) error {
+	statuses, err := customResourceStatuses(r.RESTMapper(), kubestatemetrics.CustomResources(cell))
+	if err != nil {
+		return err
+	}
+	cell.Status.CustomResources = statuses
+
+	return nil
+}
+
+func customResourceStatuses(mapper meta.RESTMapper, resources []kubestatemetrics.CustomResource) ([]monitoringv1beta1.CustomResourceStatus, error) {
+	var statuses []monitoringv1beta1.CustomResourceStatus
+	for _, resource := range resources {
+		status := monitoringv1beta1.CustomResourceStatus{Group: resource.Group, Kind: resource.Kind}
+		if kubestatemetrics.BuiltinGroup(resource.Group) {
+			// kube-state-metrics already has the access it needs to built-in resources, anything beyond
+			// that, e.g. Secrets or RBAC, must not be grantable through a Cell.
+			status.Message = "resources of built-in API groups can't be granted"
+			statuses = append(statuses, status)
+			continue
+		}
+
+		var versions []string
+		if resource.Version != "" {
+			versions = append(versions, resource.Version)
+		}
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: resource.Group, Kind: resource.Kind}, versions...)
+		if meta.IsNoMatchError(err) {
+			status.Message = "the API server doesn't serve the resource"
+			statuses = append(statuses, status)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		status.Resource = mapping.Resource.Resource
+		if resource.ResourcePlural != "" && resource.ResourcePlural != status.Resource {
+			status.Message = "resourcePlural " + resource.ResourcePlural + " doesn't match the served resource " + status.Resource
+		} else {
+			status.Granted = true
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+)
+
+var _ = Describe("customResourceStatuses", func() {
+	var mapper *meta.DefaultRESTMapper
+
+	BeforeEach(func() {
+		mapper = meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Group: "workspace.gitpod.io", Version: "v1", Kind: "Workspace"}, meta.RESTScopeNamespace)
+		mapper.AddSpecific(
+			schema.GroupVersionKind{Group: "policy.example.com", Version: "v1", Kind: "Policy"},
+			schema.GroupVersionResource{Group: "policy.example.com", Version: "v1", Resource: "policies"},
+			schema.GroupVersionResource{Group: "policy.example.com", Version: "v1", Resource: "policy"},
+			meta.RESTScopeNamespace,
+		)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	})
+
+	It("grants served custom resources under the plural the API server serves them as", func() {
+		statuses, err := customResourceStatuses(mapper, []kubestatemetrics.CustomResource{
+			{Group: "workspace.gitpod.io", Version: "v1", Kind: "Workspace"},
+			{Group: "policy.example.com", Version: "v1", Kind: "Policy"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses).To(Equal([]monitoringv1beta1.CustomResourceStatus{
+			{Group: "workspace.gitpod.io", Kind: "Workspace", Resource: "workspaces", Granted: true},
+			{Group: "policy.example.com", Kind: "Policy", Resource: "policies", Granted: true},
+		}))
+	})
+
+	It("doesn't grant resources of built-in API groups", func() {
+		statuses, err := customResourceStatuses(mapper, []kubestatemetrics.CustomResource{
+			{Version: "v1", Kind: "Secret"},
+			{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+			{Group: "apps", Version: "v1", Kind: "Deployment"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses).To(HaveLen(3))
+		for _, status := range statuses {
+			Expect(status.Granted).To(BeFalse())
+			Expect(status.Message).To(ContainSubstring("built-in API groups"))
+		}
+	})
+
+	It("doesn't grant resources the API server doesn't serve", func() {
+		statuses, err := customResourceStatuses(mapper, []kubestatemetrics.CustomResource{
+			{Group: "workspace.gitpod.io", Version: "v1", Kind: "Snapshot"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses).To(Equal([]monitoringv1beta1.CustomResourceStatus{
+			{Group: "workspace.gitpod.io", Kind: "Snapshot", Message: "the API server doesn't serve the resource"},
+		}))
+	})
+
+	It("doesn't grant a resourcePlural other than the served one", func() {
+		statuses, err := customResourceStatuses(mapper, []kubestatemetrics.CustomResource{
+			{Group: "workspace.gitpod.io", Version: "v1", Kind: "Workspace", ResourcePlural: "secrets"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(statuses).To(HaveLen(1))
+		Expect(statuses[0].Granted).To(BeFalse())
+		Expect(statuses[0].Resource).To(Equal("workspaces"))
+	})
+})
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components"
+)
+
+// diffDepth limits how deep the dry-run diff descends into an object, e.g. spec.template.spec
+const diffDepth = 3
+
+func (r *CellReconciler) isDryRun(cell *monitoringv1beta1.Cell) bool {
+	return r.DryRun || cell.Annotations[monitoringv1beta1.DryRunAnnotation] == "true"
+}
+
+// dryRun computes the desired state of every object of the cell and compares it with the live objects
+// using server-side dry-run, following the same path as reconcileObject. Objects prune would delete are
+// listed as well. Nothing is mutated; the outcome is recorded as events and in the cell status, both only
+// when it differs from the previous dry-run.
+func (r *CellReconciler) dryRun(ctx context.Context, cell *monitoringv1beta1.Cell) error {
+	desired := components.Objects(cell)
+
+	var changes []monitoringv1beta1.ObjectChange
+	for _, obj := range desired {
+		change, err := r.dryRunObject(ctx, obj)
+		if err != nil {
+			r.Logger.Error(err, "failed to dry-run object", "kind", obj.GetObjectKind().GroupVersionKind().Kind, "name", obj.GetName())
+			return err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	pruned, err := r.prunable(ctx, cell, desired)
+	if err != nil {
+		return err
+	}
+	for _, obj := range pruned {
+		changes = append(changes, monitoringv1beta1.ObjectChange{
+			Kind:      obj.GetObjectKind().GroupVersionKind().Kind,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Action:    "Delete",
+		})
+	}
+
+	if cell.Status.DryRun != nil && equality.Semantic.DeepEqual(cell.Status.DryRun.Changes, changes) {
+		return nil
+	}
+
+	for _, change := range changes {
+		msg := fmt.Sprintf("would %s %s %s", strings.ToLower(change.Action), change.Kind, objectRef(change.Namespace, change.Name))
+		if len(change.Fields) > 0 {
+			msg = fmt.Sprintf("%s: %s", msg, strings.Join(change.Fields, ", "))
+		}
+		r.Recorder.Event(cell, corev1.EventTypeNormal, "DryRun", msg)
+	}
+	if len(changes) == 0 {
+		r.Recorder.Event(cell, corev1.EventTypeNormal, "DryRun", "all objects are up to date")
+	}
+
+	cell.Status.DryRun = &monitoringv1beta1.DryRunStatus{
+		Time:    metav1.Now(),
+		Changes: changes,
+	}
+
+	return nil
+}
+
+// dryRunObject returns how desired would change the live object, or nil if it's up to date.
+func (r *CellReconciler) dryRunObject(ctx context.Context, desired client.Object) (*monitoringv1beta1.ObjectChange, error) {
+	gvk := desired.GetObjectKind().GroupVersionKind()
+	change := &monitoringv1beta1.ObjectChange{
+		Kind:      gvk.Kind,
+		Namespace: desired.GetNamespace(),
+		Name:      desired.GetName(),
+	}
+
+	current := reflect.New(reflect.TypeOf(desired).Elem()).Interface().(client.Object)
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), current)
+	if client.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+	if apierrors.IsNotFound(err) {
+		// Still run the create past the API server so invalid objects are caught now rather than on rollout.
+		if err := r.Create(ctx, desired.DeepCopyObject().(client.Object), client.DryRunAll); err != nil {
+			return nil, err
+		}
+		change.Action = "Create"
+		return change, nil
+	}
+
+	live, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := mutate(current, desired); err != nil {
+		return nil, err
+	}
+	if err := r.Update(ctx, current, client.DryRunAll); err != nil {
+		return nil, err
+	}
+	updated, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+	if err != nil {
+		return nil, err
+	}
+
+	change.Fields = diffFields(withoutServerFields(live), withoutServerFields(updated), "", diffDepth)
+	if len(change.Fields) == 0 {
+		return nil, nil
+	}
+	change.Action = "Update"
+
+	return change, nil
+}
+
+// withoutServerFields strips the fields the API server maintains, which change with every write.
+func withoutServerFields(obj map[string]interface{}) map[string]interface{} {
+	obj = runtime.DeepCopyJSON(obj)
+	delete(obj, "status")
+	for _, field := range []string{"managedFields", "resourceVersion", "generation", "creationTimestamp", "uid"} {
+		unstructured.RemoveNestedField(obj, "metadata", field)
+	}
+
+	return obj
+}
+
+// diffFields returns the sorted paths at which live and desired differ, descending at most depth levels.
+func diffFields(live, desired map[string]interface{}, prefix string, depth int) []string {
+	keys := map[string]struct{}{}
+	for k := range live {
+		keys[k] = struct{}{}
+	}
+	for k := range desired {
+		keys[k] = struct{}{}
+	}
+
+	var fields []string
+	for k := range keys {
+		if equality.Semantic.DeepEqual(live[k], desired[k]) {
+			continue
+		}
+
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+
+		l, lok := live[k].(map[string]interface{})
+		d, dok := desired[k].(map[string]interface{})
+		if depth > 1 && lok && dok {
+			fields = append(fields, diffFields(l, d, path, depth-1)...)
+			continue
+		}
+		fields = append(fields, path)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+func objectRef(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
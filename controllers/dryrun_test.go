@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("dryRunObject", func() {
+	var (
+		ctx = context.Background()
+		r   *CellReconciler
+	)
+
+	deployment := func(replicas int32, image string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-state-metrics", Namespace: "monitoring", Labels: map[string]string{"app": "kube-state-metrics"}},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: pointer.Int32(replicas),
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "kube-state-metrics", Image: image}}},
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		r = &CellReconciler{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+	})
+
+	It("reports objects that don't exist yet as created, without creating them", func() {
+		change, err := r.dryRunObject(ctx, deployment(1, "ksm:v2.7.0"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change).To(Equal(&monitoringv1beta1.ObjectChange{
+			Kind: "Deployment", Namespace: "monitoring", Name: "kube-state-metrics", Action: "Create",
+		}))
+
+		err = r.Get(ctx, client.ObjectKey{Namespace: "monitoring", Name: "kube-state-metrics"}, &appsv1.Deployment{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("reports nothing for objects that are up to date", func() {
+		Expect(r.Create(ctx, deployment(1, "ksm:v2.7.0"))).To(Succeed())
+
+		change, err := r.dryRunObject(ctx, deployment(1, "ksm:v2.7.0"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change).To(BeNil())
+	})
+
+	It("reports the fields an update changes, without updating", func() {
+		Expect(r.Create(ctx, deployment(1, "ksm:v2.7.0"))).To(Succeed())
+
+		change, err := r.dryRunObject(ctx, deployment(2, "ksm:v2.8.0"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(change.Action).To(Equal("Update"))
+		// The container is three levels down, so only the pod spec is reported
+		Expect(change.Fields).To(Equal([]string{"spec.replicas", "spec.template.spec"}))
+
+		live := &appsv1.Deployment{}
+		Expect(r.Get(ctx, client.ObjectKey{Namespace: "monitoring", Name: "kube-state-metrics"}, live)).To(Succeed())
+		Expect(*live.Spec.Replicas).To(BeEquivalentTo(1))
+	})
+})
+
+var _ = Describe("diffFields", func() {
+	DescribeTable("paths at which objects differ",
+		func(live, desired map[string]interface{}, depth int, fields []string) {
+			Expect(diffFields(live, desired, "", depth)).To(Equal(fields))
+		},
+		Entry("none for equal objects",
+			map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+			map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+			3, nil),
+		Entry("changed, added and removed fields, sorted",
+			map[string]interface{}{"data": map[string]interface{}{"b": "1", "c": "1", "d": "1"}},
+			map[string]interface{}{"data": map[string]interface{}{"a": "1", "b": "2", "d": "1"}},
+			3, []string{"data.a", "data.b", "data.c"}),
+		Entry("a field that's no longer an object",
+			map[string]interface{}{"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "a"}}},
+			map[string]interface{}{"spec": map[string]interface{}{"selector": "app=a"}},
+			3, []string{"spec.selector"}),
+		Entry("the enclosing field beyond the depth",
+			map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"hostNetwork": false}}}},
+			map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"hostNetwork": true}}}},
+			2, []string{"spec.template"}),
+		Entry("fields down to the depth",
+			map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"hostNetwork": false}}}},
+			map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"hostNetwork": true}}}},
+			4, []string{"spec.template.spec.hostNetwork"}),
+	)
+})
+
+var _ = Describe("withoutServerFields", func() {
+	It("strips the fields the API server maintains and keeps the rest", func() {
+		obj := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":              "kube-state-metrics",
+				"labels":            map[string]interface{}{"app": "kube-state-metrics"},
+				"managedFields":     []interface{}{},
+				"resourceVersion":   "42",
+				"generation":        int64(3),
+				"creationTimestamp": "2023-01-01T00:00:00Z",
+				"uid":               "9a7e",
+			},
+			"spec":   map[string]interface{}{"replicas": int64(1)},
+			"status": map[string]interface{}{"readyReplicas": int64(1)},
+		}
+
+		Expect(withoutServerFields(obj)).To(Equal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":   "kube-state-metrics",
+				"labels": map[string]interface{}{"app": "kube-state-metrics"},
+			},
+			"spec": map[string]interface{}{"replicas": int64(1)},
+		}))
+		Expect(obj).To(HaveKey("status"))
+		Expect(obj["metadata"]).To(HaveKey("resourceVersion"))
+	})
+})
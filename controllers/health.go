@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// controllerName names the Cell controller, and with it the workqueue metrics controller-runtime exports for it
+const controllerName = "cell"
+
+// cacheSyncTimeout bounds how long a readiness probe waits for the informer caches
+const cacheSyncTimeout = time.Second
+
+// ReadyzCheck reports the manager ready once its informer caches have synced. Missing CRDs are only
+// reported on the Cells: the pod also serves the conversion webhook, which must stay reachable regardless.
+func ReadyzCheck(mgr ctrl.Manager) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), cacheSyncTimeout)
+		defer cancel()
+		if !mgr.GetCache().WaitForCacheSync(ctx) {
+			return errors.New("informer caches haven't synced yet")
+		}
+
+		return nil
+	}
+}
+
+// progress keeps track of reconcile activity, so a wedged reconcile loop can be told apart from an idle one.
+type progress struct {
+	mu sync.Mutex
+	// last is when a reconcile last started or finished
+	last time.Time
+	// running is when the reconcile in flight started, zero if there's none
+	running time.Time
+}
+
+func (p *progress) start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = time.Now()
+	p.running = p.last
+}
+
+func (p *progress) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = time.Now()
+	p.running = time.Time{}
+}
+
+func (p *progress) snapshot() (last, running time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last, p.running
+}
+
+// LivenessCheck fails when the reconcile loop looks wedged: a single reconcile has been running for
+// longer than StallTimeout, or Cells are waiting in the workqueue and no reconcile started or finished
+// within StallTimeout.
+func (r *CellReconciler) LivenessCheck(_ *http.Request) error {
+	if r.StallTimeout <= 0 {
+		return nil
+	}
+
+	last, running := r.progress.snapshot()
+	if !running.IsZero() && time.Since(running) > r.StallTimeout {
+		return fmt.Errorf("reconcile has been running for %s", time.Since(running).Round(time.Second))
+	}
+
+	depth, err := queueDepth(controllerName)
+	if err != nil {
+		// Not being able to tell isn't a reason to restart the controller.
+		return nil
+	}
+	if depth > 0 && time.Since(last) > r.StallTimeout {
+		return fmt.Errorf("%d Cells are queued but no reconcile made progress for %s", int(depth), time.Since(last).Round(time.Second))
+	}
+
+	return nil
+}
+
+// queueDepth reads the current depth of the named controller's workqueue from the metrics registry.
+func queueDepth(name string) (float64, error) {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range families {
+		if f.GetName() != "workqueue_depth" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "name" && l.GetValue() == name {
+					return m.GetGauge().GetValue(), nil
+				}
+			}
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+)
+
+var _ = Describe("Health checks", func() {
+	const stallTimeout = time.Minute
+
+	// queue puts n Cells in the controller's workqueue, as far as its metrics tell
+	queue := func(n int) {
+		q := workqueue.NewNamed(controllerName)
+		for i := 0; i < n; i++ {
+			q.Add(i)
+		}
+		DeferCleanup(func() {
+			for q.Len() > 0 {
+				item, _ := q.Get()
+				q.Done(item)
+			}
+			q.ShutDown()
+		})
+	}
+
+	Describe("queueDepth", func() {
+		It("reads the depth of the named workqueue", func() {
+			queue(2)
+			Expect(queueDepth(controllerName)).To(BeEquivalentTo(2))
+		})
+
+		It("is zero for workqueues that don't exist", func() {
+			Expect(queueDepth("missing")).To(BeZero())
+		})
+	})
+
+	DescribeTable("LivenessCheck",
+		func(timeout time.Duration, queued int, sinceLast, sinceRunning time.Duration, failure string) {
+			r := &CellReconciler{StallTimeout: timeout}
+			r.progress.last = time.Now().Add(-sinceLast)
+			if sinceRunning > 0 {
+				r.progress.running = time.Now().Add(-sinceRunning)
+			}
+			queue(queued)
+
+			err := r.LivenessCheck(&http.Request{})
+			if failure == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(failure)))
+			}
+		},
+		Entry("passes while idle", stallTimeout, 0, 2*stallTimeout, time.Duration(0), ""),
+		Entry("passes while work is queued and made progress recently", stallTimeout, 3, time.Second, time.Duration(0), ""),
+		Entry("fails while work is queued without progress", stallTimeout, 3, 2*stallTimeout, time.Duration(0), "3 Cells are queued but no reconcile made progress"),
+		Entry("passes while a reconcile runs within the timeout", stallTimeout, 0, time.Second, time.Second, ""),
+		Entry("fails while a reconcile runs past the timeout", stallTimeout, 0, 2*stallTimeout, 2*stallTimeout, "reconcile has been running for 2m0s"),
+		Entry("never fails without a timeout", time.Duration(0), 3, time.Hour, time.Hour, ""),
+	)
+
+	Describe("ReadyzCheck", func() {
+		check := func(synced bool) error {
+			return ReadyzCheck(fakeManager{cache: &informertest.FakeInformers{Synced: &synced}})(&http.Request{})
+		}
+
+		It("fails until the caches synced", func() {
+			Expect(check(false)).To(MatchError("informer caches haven't synced yet"))
+		})
+
+		It("passes once the caches synced", func() {
+			Expect(check(true)).To(Succeed())
+		})
+	})
+})
+
+// fakeManager is a manager with nothing but a cache.
+type fakeManager struct {
+	ctrl.Manager
+	cache cache.Cache
+}
+
+func (m fakeManager) GetCache() cache.Cache {
+	return m.cache
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/kubernetes"
+)
+
+// discoverKubernetesComponents checks which of the Kubernetes components the cell enables run in kube-system,
+// so only those are monitored. The control plane of managed clusters isn't visible, for example. Components
+// the provider's profile knows to be unavailable aren't looked for.
+func (r *CellReconciler) discoverKubernetesComponents(ctx context.Context, cell *monitoringv1beta1.Cell) error {
+	reader := r.reader()
+
+	var statuses []monitoringv1beta1.KubernetesComponentStatus
+	for _, component := range kubernetes.EnabledComponents(cell) {
+		status := monitoringv1beta1.KubernetesComponentStatus{Name: component.Name}
+		if reason := kubernetes.Unavailable(cell, component); reason != "" {
+			status.Message = reason
+			statuses = append(statuses, status)
+			continue
+		}
+
+		// Pods aren't cached, listing a single one tells whether the component runs
+		var pods corev1.PodList
+		if err := reader.List(ctx, &pods, client.InNamespace(kubernetes.Namespace), client.MatchingLabels(component.Selector), client.Limit(1)); err != nil {
+			return err
+		}
+		if len(pods.Items) == 0 {
+			status.Message = "no pods found in " + kubernetes.Namespace + ", the component may be managed by the cloud provider"
+		} else {
+			status.Monitored = true
+		}
+		statuses = append(statuses, status)
+	}
+	cell.Status.KubernetesComponents = statuses
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/kubernetes"
+)
+
+var _ = Describe("discoverKubernetesComponents", func() {
+	var cell *monitoringv1beta1.Cell
+
+	pod := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: kubernetes.Namespace, Labels: labels}}
+	}
+	discover := func(pods ...*corev1.Pod) map[string]monitoringv1beta1.KubernetesComponentStatus {
+		builder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+		for _, p := range pods {
+			builder = builder.WithObjects(p)
+		}
+		r := &CellReconciler{Client: builder.Build()}
+		Expect(r.discoverKubernetesComponents(context.Background(), cell)).To(Succeed())
+
+		statuses := map[string]monitoringv1beta1.KubernetesComponentStatus{}
+		for _, status := range cell.Status.KubernetesComponents {
+			statuses[status.Name] = status
+		}
+		return statuses
+	}
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{}
+		cell.Spec.Kubernetes.Scheduler.Enabled = pointer.Bool(true)
+		cell.Spec.Kubernetes.Etcd.Enabled = pointer.Bool(true)
+		cell.Spec.Kubernetes.CoreDNS.Enabled = pointer.Bool(true)
+		cell.Spec.Kubernetes.KubeProxy.Enabled = pointer.Bool(true)
+	})
+
+	It("monitors the enabled components whose pods run in kube-system", func() {
+		statuses := discover(
+			pod("kube-scheduler-node-1", map[string]string{"component": "kube-scheduler"}),
+			pod("coredns-1", map[string]string{"k8s-app": "kube-dns"}),
+		)
+		Expect(statuses).To(HaveLen(4))
+		Expect(statuses).NotTo(HaveKey(kubernetes.ControllerManager.Name))
+		Expect(statuses[kubernetes.Scheduler.Name].Monitored).To(BeTrue())
+		Expect(statuses[kubernetes.CoreDNS.Name].Monitored).To(BeTrue())
+		Expect(statuses[kubernetes.KubeProxy.Name].Monitored).To(BeFalse())
+		Expect(statuses[kubernetes.KubeProxy.Name].Message).To(ContainSubstring("no pods found"))
+	})
+
+	It("doesn't monitor etcd without a client certificate", func() {
+		statuses := discover(pod("etcd-node-1", map[string]string{"component": "etcd"}))
+		Expect(statuses[kubernetes.Etcd.Name].Monitored).To(BeFalse())
+		Expect(statuses[kubernetes.Etcd.Name].Message).To(Equal("no client certificate Secret is configured"))
+
+		cell.Spec.Kubernetes.Etcd.ClientCertSecret = "etcd-client"
+		statuses = discover(pod("etcd-node-1", map[string]string{"component": "etcd"}))
+		Expect(statuses[kubernetes.Etcd.Name].Monitored).To(BeTrue())
+	})
+
+	It("doesn't look for the components the provider makes unavailable", func() {
+		cell.Spec.Provider = monitoringv1beta1.ProviderGKE
+		cell.Spec.Kubernetes.Etcd.ClientCertSecret = "etcd-client"
+		statuses := discover(
+			pod("kube-scheduler-node-1", map[string]string{"component": "kube-scheduler"}),
+			pod("etcd-node-1", map[string]string{"component": "etcd"}),
+		)
+		Expect(statuses[kubernetes.Scheduler.Name].Monitored).To(BeFalse())
+		Expect(statuses[kubernetes.Scheduler.Name].Message).To(Equal("the control plane is managed by the provider"))
+		Expect(statuses[kubernetes.Etcd.Name].Monitored).To(BeFalse())
+	})
+
+	It("finds pods by the selectors of the provider", func() {
+		cell.Spec.Provider = monitoringv1beta1.ProviderGKE
+		statuses := discover(pod("kube-proxy-node-1", map[string]string{"component": "kube-proxy"}))
+		Expect(statuses[kubernetes.KubeProxy.Name].Monitored).To(BeTrue())
+
+		cell.Spec.Provider = monitoringv1beta1.ProviderGeneric
+		statuses = discover(pod("kube-proxy-node-1", map[string]string{"component": "kube-proxy"}))
+		Expect(statuses[kubernetes.KubeProxy.Name].Monitored).To(BeFalse())
+	})
+
+	It("reports nothing when no component is enabled", func() {
+		cell.Spec.Kubernetes = monitoringv1beta1.KubernetesSpec{}
+		Expect(discover()).To(BeEmpty())
+	})
+})
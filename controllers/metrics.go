@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	prom "github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+const metricsNamespace = "monitoring_cell"
+
+var (
+	cellReady = prom.NewGaugeVec(prom.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "ready",
+		Help:      "Whether the Cell's Ready condition is true (1) or not (0).",
+	}, []string{"namespace", "cell"})
+
+	componentReady = prom.NewGaugeVec(prom.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "component_ready",
+		Help:      "Whether a component of the Cell is reported ready (1) or not (0) in its status.",
+	}, []string{"namespace", "cell", "component"})
+
+	childObjects = prom.NewGaugeVec(prom.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "child_objects",
+		Help:      "Number of objects the controller manages for the Cell, by component.",
+	}, []string{"namespace", "cell", "component"})
+
+	applyErrors = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "apply_errors_total",
+		Help:      "Number of failed creates and updates of the Cell's objects, by kind.",
+	}, []string{"namespace", "cell", "kind"})
+
+	prometheusQueryDuration = prom.NewHistogramVec(prom.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "prometheus_query_duration_seconds",
+		Help:      "Latency of the queries the controller runs against the Cell's Prometheus.",
+		Buckets:   prom.DefBuckets,
+	}, []string{"namespace", "cell"})
+
+	prometheusQueryErrors = prom.NewCounterVec(prom.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "prometheus_query_errors_total",
+		Help:      "Number of failed queries against the Cell's Prometheus.",
+	}, []string{"namespace", "cell"})
+
+	lastSuccessfulReconcile = prom.NewGaugeVec(prom.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_successful_reconcile_timestamp_seconds",
+		Help:      "Unix timestamp of the last reconcile that converged all of the Cell's objects. Paused and dry-run reconciles don't count.",
+	}, []string{"namespace", "cell"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		cellReady,
+		componentReady,
+		childObjects,
+		applyErrors,
+		prometheusQueryDuration,
+		prometheusQueryErrors,
+		lastSuccessfulReconcile,
+	)
+}
+
+// recordStatusMetrics mirrors the readiness reported in the cell status.
+func recordStatusMetrics(cell *monitoringv1beta1.Cell) {
+	ready := 0.0
+	if meta.IsStatusConditionTrue(cell.Status.Conditions, monitoringv1beta1.Ready) {
+		ready = 1
+	}
+	cellReady.WithLabelValues(cell.Namespace, cell.Name).Set(ready)
+
+	for _, c := range componentsReadiness(&cell.Status) {
+		if c.ready == nil {
+			continue
+		}
+		v := 0.0
+		if *c.ready {
+			v = 1
+		}
+		componentReady.WithLabelValues(cell.Namespace, cell.Name, c.name).Set(v)
+	}
+}
+
+// forgetCellMetrics removes all series of a cell that no longer exists.
+func forgetCellMetrics(cell types.NamespacedName) {
+	labels := prom.Labels{"namespace": cell.Namespace, "cell": cell.Name}
+	for _, vec := range []interface {
+		DeletePartialMatch(prom.Labels) int
+	}{
+		cellReady,
+		componentReady,
+		childObjects,
+		applyErrors,
+		prometheusQueryDuration,
+		prometheusQueryErrors,
+		lastSuccessfulReconcile,
+	} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+func markReconciled(cell *monitoringv1beta1.Cell) {
+	lastSuccessfulReconcile.WithLabelValues(cell.Namespace, cell.Name).SetToCurrentTime()
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("Metrics", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "metrics"}}
+		// The metrics are global, start each spec without the series of others
+		for _, vec := range []interface{ Reset() }{
+			cellReady, componentReady, childObjects, applyErrors, prometheusQueryDuration, prometheusQueryErrors, lastSuccessfulReconcile,
+		} {
+			vec.Reset()
+		}
+	})
+
+	It("mirror the readiness of the cell and of the components it reports on", func() {
+		cell.Status.Conditions = []metav1.Condition{{Type: monitoringv1beta1.Ready, Status: metav1.ConditionTrue, Reason: "ComponentsReady"}}
+		cell.Status.PrometheusReady = pointer.Bool(true)
+		cell.Status.KubeletReady = pointer.Bool(false)
+		recordStatusMetrics(cell)
+
+		Expect(testutil.CollectAndCompare(cellReady, strings.NewReader(`
+# HELP monitoring_cell_ready Whether the Cell's Ready condition is true (1) or not (0).
+# TYPE monitoring_cell_ready gauge
+monitoring_cell_ready{cell="cell",namespace="metrics"} 1
+`))).To(Succeed())
+		// Components that haven't been checked yet have no series
+		Expect(testutil.CollectAndCompare(componentReady, strings.NewReader(`
+# HELP monitoring_cell_component_ready Whether a component of the Cell is reported ready (1) or not (0) in its status.
+# TYPE monitoring_cell_component_ready gauge
+monitoring_cell_component_ready{cell="cell",component="kubelet",namespace="metrics"} 0
+monitoring_cell_component_ready{cell="cell",component="prometheus",namespace="metrics"} 1
+`))).To(Succeed())
+
+		cell.Status.Conditions[0].Status = metav1.ConditionFalse
+		recordStatusMetrics(cell)
+		Expect(testutil.ToFloat64(cellReady.WithLabelValues("metrics", "cell"))).To(BeZero())
+	})
+
+	It("count the objects of a component, including those held back, and the failures to apply them", func() {
+		r := &CellReconciler{
+			Client: stubWrites{
+				Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+				create: func(client.Object) error { return errors.New("quota exceeded") },
+			},
+			Logger:   logr.Discard(),
+			Recorder: record.NewFakeRecorder(10),
+		}
+		desired := []client.Object{
+			&pomonitoringv1.ServiceMonitor{TypeMeta: metav1.TypeMeta{APIVersion: pomonitoringv1.SchemeGroupVersion.String(), Kind: "ServiceMonitor"}},
+			&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "kube-state-metrics-cell", Namespace: "metrics"},
+			},
+		}
+
+		Expect(r.reconcileObjects(context.Background(), cell, "exporters", desired)).To(MatchError("quota exceeded"))
+		Expect(testutil.ToFloat64(childObjects.WithLabelValues("metrics", "cell", "exporters"))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(applyErrors.WithLabelValues("metrics", "cell", "ConfigMap"))).To(Equal(1.0))
+		Expect(testutil.CollectAndCount(applyErrors)).To(Equal(1))
+	})
+
+	It("time the queries against Prometheus and count the failed ones", func() {
+		failing := false
+		prometheusAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if failing {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			fakePrometheusQuery(w, req)
+		}))
+		DeferCleanup(prometheusAPI.Close)
+		podRESTClient, err := rest.RESTClientFor(&rest.Config{
+			Host:    prometheusAPI.URL,
+			APIPath: "/api",
+			ContentConfig: rest.ContentConfig{
+				GroupVersion:         &corev1.SchemeGroupVersion,
+				NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		r := &CellReconciler{PodRESTClient: podRESTClient, Logger: logr.Discard(), Recorder: record.NewFakeRecorder(10)}
+
+		Expect(r.isExporterReady(context.Background(), cell, `up{job="node-exporter"}`, 1)).To(BeTrue())
+		failing = true
+		_, err = r.isExporterReady(context.Background(), cell, `up{job="node-exporter"}`, 1)
+		Expect(err).To(HaveOccurred())
+
+		Expect(queries(cell)).To(BeEquivalentTo(2))
+		Expect(testutil.ToFloat64(prometheusQueryErrors.WithLabelValues("metrics", "cell"))).To(Equal(1.0))
+	})
+
+	It("record when the cell was last reconciled", func() {
+		before := float64(time.Now().Unix())
+		markReconciled(cell)
+		Expect(testutil.ToFloat64(lastSuccessfulReconcile.WithLabelValues("metrics", "cell"))).To(BeNumerically(">=", before))
+	})
+
+	It("forget the series of a deleted cell and keep those of others", func() {
+		other := &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "metrics"}}
+		for _, c := range []*monitoringv1beta1.Cell{cell, other} {
+			c.Status.PrometheusReady = pointer.Bool(true)
+			recordStatusMetrics(c)
+			markReconciled(c)
+			childObjects.WithLabelValues(c.Namespace, c.Name, "exporters").Set(3)
+		}
+
+		forgetCellMetrics(types.NamespacedName{Namespace: "metrics", Name: "cell"})
+		for _, collector := range []prom.Collector{cellReady, componentReady, childObjects, lastSuccessfulReconcile} {
+			Expect(testutil.CollectAndCount(collector)).To(Equal(1))
+		}
+		Expect(testutil.ToFloat64(childObjects.WithLabelValues("metrics", "other", "exporters"))).To(Equal(3.0))
+	})
+})
+
+// queries returns how many queries against the Prometheus of the cell were timed.
+func queries(cell *monitoringv1beta1.Cell) uint64 {
+	registry := prom.NewRegistry()
+	Expect(registry.Register(prometheusQueryDuration)).To(Succeed())
+	families, err := registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["namespace"] == cell.Namespace && labels["cell"] == cell.Name {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+
+	return 0
+}
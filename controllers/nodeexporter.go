@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+// checkNodeExporterProfiles reports nodes selected by more than one node-exporter profile. Whether node
+// selectors overlap depends on the labels of the nodes, so it can't be validated on the Cell itself.
+func (r *CellReconciler) checkNodeExporterProfiles(ctx context.Context, cell *monitoringv1beta1.Cell) error {
+	var overlaps []string
+	if len(cell.Spec.NodeExporter.Profiles) > 1 {
+		// Nodes aren't cached, only their labels are needed
+		var nodes metav1.PartialObjectMetadataList
+		nodes.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NodeList"))
+		if err := r.reader().List(ctx, &nodes); err != nil {
+			return err
+		}
+		overlaps = overlappingProfiles(cell.Spec.NodeExporter.Profiles, nodes.Items)
+	}
+
+	if len(overlaps) == 0 {
+		meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+			Type:    monitoringv1beta1.NodeExporterProfilesOverlap,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoOverlap",
+			Message: "every node is selected by at most one node-exporter profile",
+		})
+		return nil
+	}
+
+	msg := "nodes are selected by more than one node-exporter profile: " + strings.Join(overlaps, "; ")
+	if !meta.IsStatusConditionTrue(cell.Status.Conditions, monitoringv1beta1.NodeExporterProfilesOverlap) {
+		r.Recorder.Event(cell, corev1.EventTypeWarning, "NodeExporterProfilesOverlap", msg)
+	}
+	meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+		Type:    monitoringv1beta1.NodeExporterProfilesOverlap,
+		Status:  metav1.ConditionTrue,
+		Reason:  "NodeSelectorsOverlap",
+		Message: msg,
+	})
+	return nil
+}
+
+// overlappingProfiles lists the nodes selected by more than one profile, along with the profiles selecting them.
+func overlappingProfiles(profiles []monitoringv1beta1.NodeExporterProfile, nodes []metav1.PartialObjectMetadata) []string {
+	var overlaps []string
+	for _, node := range nodes {
+		var selectedBy []string
+		for _, profile := range profiles {
+			if labels.SelectorFromSet(profile.NodeSelector).Matches(labels.Set(node.Labels)) {
+				selectedBy = append(selectedBy, profile.Name)
+			}
+		}
+		if len(selectedBy) > 1 {
+			overlaps = append(overlaps, node.Name+" ("+strings.Join(selectedBy, ", ")+")")
+		}
+	}
+	sort.Strings(overlaps)
+
+	return overlaps
+}
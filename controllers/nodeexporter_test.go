@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("overlappingProfiles", func() {
+	node := func(name string, labels map[string]string) metav1.PartialObjectMetadata {
+		return metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	nodes := []metav1.PartialObjectMetadata{
+		node("workspace-1", map[string]string{"pool": "workspace", "zone": "a"}),
+		node("services-1", map[string]string{"pool": "services", "zone": "a"}),
+		node("services-2", map[string]string{"pool": "services", "zone": "b"}),
+	}
+
+	It("finds no overlap between profiles selecting distinct node pools", func() {
+		profiles := []monitoringv1beta1.NodeExporterProfile{
+			{Name: "workspace", NodeSelector: map[string]string{"pool": "workspace"}},
+			{Name: "services", NodeSelector: map[string]string{"pool": "services"}},
+		}
+		Expect(overlappingProfiles(profiles, nodes)).To(BeEmpty())
+	})
+
+	It("lists the nodes selected by more than one profile", func() {
+		profiles := []monitoringv1beta1.NodeExporterProfile{
+			{Name: "services", NodeSelector: map[string]string{"pool": "services"}},
+			{Name: "zone-a", NodeSelector: map[string]string{"zone": "a"}},
+		}
+		Expect(overlappingProfiles(profiles, nodes)).To(Equal([]string{"services-1 (services, zone-a)"}))
+	})
+
+	It("treats a profile without a node selector as selecting every node", func() {
+		profiles := []monitoringv1beta1.NodeExporterProfile{
+			{Name: "all"},
+			{Name: "workspace", NodeSelector: map[string]string{"pool": "workspace"}},
+		}
+		Expect(overlappingProfiles(profiles, nodes)).To(Equal([]string{"workspace-1 (all, workspace)"}))
+	})
+})
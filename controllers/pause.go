@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"time"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+// pausedResync is how often the status of a paused cell is refreshed
+const pausedResync = time.Minute
+
+// isPaused reports whether changes to the cell's objects are suspended at the given time.
+// A pause with an expiry ends on its own once the expiry has passed.
+func isPaused(cell *monitoringv1beta1.Cell, now time.Time) bool {
+	if !cell.Spec.Paused {
+		return false
+	}
+	if cell.Spec.PausedUntil == nil {
+		return true
+	}
+
+	return now.Before(cell.Spec.PausedUntil.Time)
+}
+
+// pausedRequeueAfter returns when a paused cell should be looked at again: either to refresh
+// its status or to resume it, whichever comes first.
+func pausedRequeueAfter(cell *monitoringv1beta1.Cell, now time.Time) time.Duration {
+	if cell.Spec.PausedUntil == nil {
+		return pausedResync
+	}
+	if until := cell.Spec.PausedUntil.Sub(now); until < pausedResync {
+		return until
+	}
+
+	return pausedResync
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("Pausing a cell", func() {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// paused returns a cell paused until now plus until, or indefinitely if until is nil
+	paused := func(until *time.Duration) *monitoringv1beta1.Cell {
+		cell := &monitoringv1beta1.Cell{}
+		cell.Spec.Paused = true
+		if until != nil {
+			cell.Spec.PausedUntil = &metav1.Time{Time: now.Add(*until)}
+		}
+		return cell
+	}
+	in := func(d time.Duration) *time.Duration { return &d }
+
+	DescribeTable("isPaused",
+		func(cell *monitoringv1beta1.Cell, expected bool) {
+			Expect(isPaused(cell, now)).To(Equal(expected))
+		},
+		Entry("not paused", &monitoringv1beta1.Cell{}, false),
+		Entry("paused without an expiry", paused(nil), true),
+		Entry("paused until later", paused(in(time.Hour)), true),
+		Entry("paused until now", paused(in(0)), false),
+		Entry("paused until earlier", paused(in(-time.Hour)), false),
+	)
+
+	DescribeTable("pausedRequeueAfter",
+		func(cell *monitoringv1beta1.Cell, expected time.Duration) {
+			Expect(pausedRequeueAfter(cell, now)).To(Equal(expected))
+		},
+		Entry("refreshes the status of a pause without an expiry", paused(nil), pausedResync),
+		Entry("refreshes the status of a pause that lasts longer", paused(in(time.Hour)), pausedResync),
+		Entry("resumes a pause that ends sooner", paused(in(20*time.Second)), 20*time.Second),
+	)
+
+	DescribeTable("the Ready condition",
+		func(cell *monitoringv1beta1.Cell, status metav1.ConditionStatus, reason, message string) {
+			condition := readyCondition(cell, now)
+			Expect(condition.Type).To(Equal(monitoringv1beta1.Ready))
+			Expect(condition.Status).To(Equal(status))
+			Expect(condition.Reason).To(Equal(reason))
+			Expect(condition.Message).To(Equal(message))
+		},
+		Entry("paused without an expiry", paused(nil),
+			metav1.ConditionFalse, "Paused", "reconciliation is paused"),
+		Entry("paused until later", paused(in(time.Hour)),
+			metav1.ConditionFalse, "Paused", "reconciliation is paused until 2023-01-01T13:00:00Z"),
+		Entry("after the pause expired", paused(in(-time.Hour)),
+			metav1.ConditionFalse, "ComponentsNotReady", "components not ready: prometheus-operator, prometheus, node-exporter, kube-state-metrics, kubelet, apiserver"),
+		Entry("after the pause expired with every component ready", readyCell(paused(in(-time.Hour))),
+			metav1.ConditionTrue, "ComponentsReady", "all components are ready"),
+		Entry("paused with every component ready", readyCell(paused(nil)),
+			metav1.ConditionFalse, "Paused", "reconciliation is paused"),
+	)
+})
+
+// readyCell reports every component of the cell as ready.
+func readyCell(cell *monitoringv1beta1.Cell) *monitoringv1beta1.Cell {
+	cell.Status.PrometheusOperatorReady = pointer.Bool(true)
+	cell.Status.PrometheusReady = pointer.Bool(true)
+	cell.Status.NodeExporterReady = pointer.Bool(true)
+	cell.Status.KubeStateMetricsReady = pointer.Bool(true)
+	cell.Status.KubeletReady = pointer.Bool(true)
+	cell.Status.APIServerReady = pointer.Bool(true)
+	return cell
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/prometheus"
+)
+
+const (
+	samplesFailedQuery  = `rate(prometheus_remote_storage_samples_failed_total[5m])`
+	samplesDroppedQuery = `rate(prometheus_remote_storage_samples_dropped_total[5m])`
+	lagQuery            = `prometheus_remote_storage_highest_timestamp_in_seconds - ignoring(remote_name, url) group_right prometheus_remote_storage_queue_highest_sent_timestamp_seconds`
+	shardsQuery         = `prometheus_remote_storage_shards`
+	desiredShardsQuery  = `prometheus_remote_storage_shards_desired`
+
+	// maxRemoteWriteLag is how far an endpoint may fall behind ingestion before it's considered unhealthy
+	maxRemoteWriteLag = 5 * time.Minute
+)
+
+// checkRemoteWriteHealth queries Prometheus for the state of its remote-write queues, and summarises it per
+// endpoint in the cell status. Series are matched to named endpoints by their remote_name label, which
+// prometheus-operator sets to the endpoint's name, and to endpoints without a name by their url label.
+func (r *CellReconciler) checkRemoteWriteHealth(cell *monitoringv1beta1.Cell) {
+	if len(cell.Status.RemoteWrite) == 0 {
+		meta.RemoveStatusCondition(&cell.Status.Conditions, monitoringv1beta1.RemoteWriteHealthy)
+		return
+	}
+
+	if cell.Status.PrometheusReady == nil || !*cell.Status.PrometheusReady {
+		meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+			Type:    monitoringv1beta1.RemoteWriteHealthy,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "PrometheusNotReady",
+			Message: "remote-write health can't be checked while Prometheus isn't ready",
+		})
+		return
+	}
+
+	results := map[string]remoteWriteSamples{}
+	for _, query := range []string{samplesFailedQuery, samplesDroppedQuery, lagQuery, shardsQuery, desiredShardsQuery} {
+		samples, err := r.queryVector(cell, query)
+		if err != nil {
+			meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+				Type:    monitoringv1beta1.RemoteWriteHealthy,
+				Status:  metav1.ConditionUnknown,
+				Reason:  "QueryFailed",
+				Message: fmt.Sprintf("querying Prometheus for remote-write metrics failed: %v", err),
+			})
+			return
+		}
+		grouped := remoteWriteSamples{byName: map[string]float64{}, byURL: map[string]float64{}}
+		for _, s := range samples {
+			grouped.byName[s.Labels["remote_name"]] += s.Value
+			grouped.byURL[s.Labels["url"]] += s.Value
+		}
+		results[query] = grouped
+	}
+
+	var unhealthy, unknown []string
+	for i := range cell.Status.RemoteWrite {
+		status := &cell.Status.RemoteWrite[i]
+		summariseRemoteWrite(status, results)
+		switch {
+		case status.Healthy == nil:
+			unknown = append(unknown, status.Name)
+		case !*status.Healthy:
+			unhealthy = append(unhealthy, status.Name+": "+status.HealthMessage)
+		}
+	}
+
+	switch {
+	case len(unhealthy) > 0:
+		msg := "remote write is failing for " + strings.Join(unhealthy, "; ")
+		if !meta.IsStatusConditionFalse(cell.Status.Conditions, monitoringv1beta1.RemoteWriteHealthy) {
+			r.Recorder.Event(cell, corev1.EventTypeWarning, "RemoteWriteFailing", msg)
+		}
+		meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+			Type:    monitoringv1beta1.RemoteWriteHealthy,
+			Status:  metav1.ConditionFalse,
+			Reason:  "RemoteWriteFailing",
+			Message: msg,
+		})
+	case len(unknown) > 0:
+		meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+			Type:    monitoringv1beta1.RemoteWriteHealthy,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "NoMetrics",
+			Message: "Prometheus hasn't reported remote-write metrics for " + strings.Join(unknown, ", "),
+		})
+	default:
+		meta.SetStatusCondition(&cell.Status.Conditions, metav1.Condition{
+			Type:    monitoringv1beta1.RemoteWriteHealthy,
+			Status:  metav1.ConditionTrue,
+			Reason:  "RemoteWriteSucceeding",
+			Message: "samples are sent to all remote-write endpoints",
+		})
+	}
+}
+
+// remoteWriteSamples sums the samples of a query per remote-write queue name and URL. Several endpoints can
+// share a URL, e.g. with different credentials, so only unnamed endpoints are matched by it.
+type remoteWriteSamples struct {
+	byName map[string]float64
+	byURL  map[string]float64
+}
+
+// summariseRemoteWrite records the query results for an endpoint in its status, and judges its health.
+func summariseRemoteWrite(status *monitoringv1beta1.RemoteWriteStatus, results map[string]remoteWriteSamples) {
+	status.Healthy = nil
+	status.HealthMessage = ""
+	status.SamplesFailedRate = ""
+	status.SamplesDroppedRate = ""
+	status.LagSeconds = nil
+	status.Shards = nil
+	status.DesiredShards = nil
+
+	value := func(query string) (float64, bool) {
+		// The status of an endpoint without a name is named after its URL.
+		if status.Name == status.URL {
+			v, ok := results[query].byURL[status.URL]
+			return v, ok
+		}
+		v, ok := results[query].byName[status.Name]
+		return v, ok
+	}
+
+	failed, hasFailed := value(samplesFailedQuery)
+	if hasFailed {
+		status.SamplesFailedRate = formatRate(failed)
+	}
+	if dropped, ok := value(samplesDroppedQuery); ok {
+		status.SamplesDroppedRate = formatRate(dropped)
+	}
+	lag, hasLag := value(lagQuery)
+	if hasLag {
+		status.LagSeconds = pointer.Int64(int64(lag))
+	}
+	if shards, ok := value(shardsQuery); ok {
+		status.Shards = pointer.Int32(int32(shards))
+	}
+	if desired, ok := value(desiredShardsQuery); ok {
+		status.DesiredShards = pointer.Int32(int32(desired))
+	}
+
+	if !hasFailed && !hasLag {
+		return
+	}
+
+	var problems []string
+	if failed > 0 {
+		problems = append(problems, fmt.Sprintf("%s samples/s failed to be sent", status.SamplesFailedRate))
+	}
+	if lag > maxRemoteWriteLag.Seconds() {
+		problems = append(problems, fmt.Sprintf("sending lags %s behind ingestion", time.Duration(lag)*time.Second))
+	}
+	healthy := len(problems) == 0
+	status.Healthy = &healthy
+	status.HealthMessage = strings.Join(problems, ", ")
+}
+
+func formatRate(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// queryVector queries Prometheus for an instant vector, recording the duration and failures of the query.
+func (r *CellReconciler) queryVector(cell *monitoringv1beta1.Cell, query string) ([]prometheus.Sample, error) {
+	timer := prom.NewTimer(prometheusQueryDuration.WithLabelValues(cell.Namespace, cell.Name))
+	samples, err := prometheus.QueryVector(query, cell, r.PodRESTClient)
+	timer.ObserveDuration()
+	if err != nil {
+		prometheusQueryErrors.WithLabelValues(cell.Namespace, cell.Name).Inc()
+		r.Recorder.Eventf(cell, corev1.EventTypeWarning, "QueryFailed", "Querying Prometheus for %q failed: %v", query, err)
+		return nil, err
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/utils/pointer"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("Remote-write health", func() {
+	const url = "https://metrics.example.com/api/v1/write"
+
+	// Two tenants writing to the same URL, and a third endpoint without a name
+	results := map[string]remoteWriteSamples{
+		samplesFailedQuery: {
+			byName: map[string]float64{"tenant-a": 0, "tenant-b": 3, "1a2b3c": 0},
+			byURL:  map[string]float64{url: 3, "https://other.example.com": 0},
+		},
+		lagQuery: {
+			byName: map[string]float64{"tenant-a": 10, "tenant-b": 10, "1a2b3c": 600},
+			byURL:  map[string]float64{url: 20, "https://other.example.com": 600},
+		},
+		shardsQuery: {
+			byName: map[string]float64{"tenant-a": 2},
+			byURL:  map[string]float64{url: 4},
+		},
+	}
+
+	It("matches named endpoints by their remote_name", func() {
+		a := monitoringv1beta1.RemoteWriteStatus{Name: "tenant-a", URL: url}
+		summariseRemoteWrite(&a, results)
+		Expect(a.Healthy).To(Equal(pointer.Bool(true)))
+		Expect(a.LagSeconds).To(Equal(pointer.Int64(10)))
+		Expect(a.Shards).To(Equal(pointer.Int32(2)))
+
+		b := monitoringv1beta1.RemoteWriteStatus{Name: "tenant-b", URL: url}
+		summariseRemoteWrite(&b, results)
+		Expect(b.Healthy).To(Equal(pointer.Bool(false)))
+		Expect(b.SamplesFailedRate).To(Equal("3.00"))
+		Expect(b.Shards).To(BeNil())
+	})
+
+	It("matches endpoints without a name by their URL", func() {
+		status := monitoringv1beta1.RemoteWriteStatus{Name: "https://other.example.com", URL: "https://other.example.com"}
+		summariseRemoteWrite(&status, results)
+		Expect(status.Healthy).To(Equal(pointer.Bool(false)))
+		Expect(status.HealthMessage).To(ContainSubstring("lags 10m0s behind"))
+	})
+
+	It("leaves the health of endpoints without metrics unknown", func() {
+		status := monitoringv1beta1.RemoteWriteStatus{Name: "tenant-c", URL: url}
+		summariseRemoteWrite(&status, results)
+		Expect(status.Healthy).To(BeNil())
+		Expect(status.LagSeconds).To(BeNil())
+	})
+})
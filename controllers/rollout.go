@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+const (
+	// defaultRolloutDeadline is how long a workload may take to roll out a change unless the cell says otherwise
+	defaultRolloutDeadline = 10 * time.Minute
+	// rolloutPoll is how often a cell is checked while one of its workloads is rolling out
+	rolloutPoll = 30 * time.Second
+)
+
+func rolloutDeadline(cell *monitoringv1beta1.Cell) time.Duration {
+	if cell.Spec.RolloutDeadline == nil {
+		return defaultRolloutDeadline
+	}
+
+	return cell.Spec.RolloutDeadline.Duration
+}
+
+// rolloutTarget decides what to apply for a desired object. Each workload's rollout is tracked on its own:
+// a change starts a new rollout, superseding one still in progress, without waiting for other workloads.
+// A workload whose rollout failed keeps its last known-good spec until the desired spec changes again.
+func (r *CellReconciler) rolloutTarget(ctx context.Context, cell *monitoringv1beta1.Cell, desired client.Object, now time.Time) (client.Object, error) {
+	if newWorkload(desired.GetObjectKind().GroupVersionKind().Kind) == nil {
+		return desired, nil
+	}
+	kind := desired.GetObjectKind().GroupVersionKind().Kind
+	hash, err := specHash(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	rollout := findRollout(cell, kind, desired.GetName())
+	switch {
+	case rollout == nil:
+		// Either the workload is new, or it predates rollout tracking. In the latter case whatever runs now
+		// is the best guess for a known-good spec.
+		lastKnownGood, err := r.rolledOutSpec(ctx, kind, client.ObjectKeyFromObject(desired))
+		if err != nil {
+			return nil, err
+		}
+		cell.Status.Rollouts = append(cell.Status.Rollouts, monitoringv1beta1.WorkloadRollout{
+			Kind:          kind,
+			Name:          desired.GetName(),
+			SpecHash:      hash,
+			Phase:         monitoringv1beta1.RolloutPhaseProgressing,
+			StartTime:     metav1.NewTime(now),
+			LastKnownGood: lastKnownGood,
+		})
+	case rollout.SpecHash != hash:
+		rollout.SpecHash = hash
+		rollout.Phase = monitoringv1beta1.RolloutPhaseProgressing
+		rollout.StartTime = metav1.NewTime(now)
+		r.Recorder.Eventf(cell, corev1.EventTypeNormal, "RolloutStarted", "Rolling out a change to %s %s", kind, objectRef(desired.GetNamespace(), desired.GetName()))
+	case rollout.Phase == monitoringv1beta1.RolloutPhaseFailed && rollout.LastKnownGood != nil:
+		return withSpec(desired, rollout.LastKnownGood.Raw)
+	}
+
+	return desired, nil
+}
+
+// trackRollouts checks on the workloads that are rolling out a change. Once one is ready, its spec becomes
+// the last known-good one. If it doesn't get ready within the deadline it's reverted to its last known-good spec.
+func (r *CellReconciler) trackRollouts(ctx context.Context, cell *monitoringv1beta1.Cell, now time.Time) error {
+	for i := range cell.Status.Rollouts {
+		rollout := &cell.Status.Rollouts[i]
+		if rollout.Phase != monitoringv1beta1.RolloutPhaseProgressing {
+			continue
+		}
+		ref := objectRef(cell.Namespace, rollout.Name)
+
+		live := newWorkload(rollout.Kind)
+		if live == nil {
+			continue
+		}
+		err := r.Get(ctx, types.NamespacedName{Namespace: cell.Namespace, Name: rollout.Name}, live)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if rolledOut(live) {
+			raw, err := json.Marshal(specOf(live))
+			if err != nil {
+				return err
+			}
+			rollout.Phase = monitoringv1beta1.RolloutPhaseComplete
+			rollout.LastKnownGood = &runtime.RawExtension{Raw: raw}
+			r.Recorder.Eventf(cell, corev1.EventTypeNormal, "RolloutComplete", "%s %s rolled out", rollout.Kind, ref)
+			continue
+		}
+
+		deadline := rolloutDeadline(cell)
+		if now.Sub(rollout.StartTime.Time) <= deadline {
+			continue
+		}
+		rollout.Phase = monitoringv1beta1.RolloutPhaseFailed
+		if rollout.LastKnownGood == nil {
+			r.Recorder.Eventf(cell, corev1.EventTypeWarning, "RolloutFailed", "%s %s didn't become ready within %s and has no known-good spec to roll back to", rollout.Kind, ref, deadline)
+			continue
+		}
+		r.Recorder.Eventf(cell, corev1.EventTypeWarning, "RolloutFailed", "%s %s didn't become ready within %s, rolling back to its last known-good spec", rollout.Kind, ref, deadline)
+		reverted, err := withSpec(live, rollout.LastKnownGood.Raw)
+		if err != nil {
+			return err
+		}
+		gvk, err := apiutil.GVKForObject(live, r.Scheme)
+		if err != nil {
+			return err
+		}
+		reverted.GetObjectKind().SetGroupVersionKind(gvk)
+		if err := r.reconcileObject(ctx, cell, reverted); err != nil {
+			return err
+		}
+	}
+
+	meta.SetStatusCondition(&cell.Status.Conditions, rolloutFailedCondition(cell))
+	return nil
+}
+
+func rolloutFailedCondition(cell *monitoringv1beta1.Cell) metav1.Condition {
+	var failed []string
+	for _, rollout := range cell.Status.Rollouts {
+		if rollout.Phase == monitoringv1beta1.RolloutPhaseFailed {
+			failed = append(failed, rollout.Kind+"/"+rollout.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return metav1.Condition{
+			Type:    monitoringv1beta1.RolloutFailed,
+			Status:  metav1.ConditionTrue,
+			Reason:  "DeadlineExceeded",
+			Message: "rollouts didn't become ready in time: " + strings.Join(failed, ", "),
+		}
+	}
+
+	return metav1.Condition{
+		Type:    monitoringv1beta1.RolloutFailed,
+		Status:  metav1.ConditionFalse,
+		Reason:  "RolloutsSucceeded",
+		Message: "no rollout failed",
+	}
+}
+
+// rolledOutSpec returns the spec of a live workload if it has finished rolling out, nil otherwise.
+func (r *CellReconciler) rolledOutSpec(ctx context.Context, kind string, key types.NamespacedName) (*runtime.RawExtension, error) {
+	live := newWorkload(kind)
+	if err := r.Get(ctx, key, live); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if !rolledOut(live) {
+		return nil, nil
+	}
+	raw, err := json.Marshal(specOf(live))
+	if err != nil {
+		return nil, err
+	}
+
+	return &runtime.RawExtension{Raw: raw}, nil
+}
+
+func findRollout(cell *monitoringv1beta1.Cell, kind, name string) *monitoringv1beta1.WorkloadRollout {
+	for i := range cell.Status.Rollouts {
+		if cell.Status.Rollouts[i].Kind == kind && cell.Status.Rollouts[i].Name == name {
+			return &cell.Status.Rollouts[i]
+		}
+	}
+
+	return nil
+}
+
+func forgetRollout(cell *monitoringv1beta1.Cell, kind, name string) {
+	rollouts := cell.Status.Rollouts[:0]
+	for _, rollout := range cell.Status.Rollouts {
+		if rollout.Kind != kind || rollout.Name != name {
+			rollouts = append(rollouts, rollout)
+		}
+	}
+	cell.Status.Rollouts = rollouts
+}
+
+func progressingRollout(cell *monitoringv1beta1.Cell) *monitoringv1beta1.WorkloadRollout {
+	for i := range cell.Status.Rollouts {
+		if cell.Status.Rollouts[i].Phase == monitoringv1beta1.RolloutPhaseProgressing {
+			return &cell.Status.Rollouts[i]
+		}
+	}
+
+	return nil
+}
+
+// newWorkload returns an empty object of a kind whose rollouts are tracked, nil for any other kind.
+func newWorkload(kind string) client.Object {
+	switch kind {
+	case "Deployment":
+		return &appsv1.Deployment{}
+	case "DaemonSet":
+		return &appsv1.DaemonSet{}
+	case "StatefulSet":
+		return &appsv1.StatefulSet{}
+	case pomonitoringv1.PrometheusesKind:
+		return &pomonitoringv1.Prometheus{}
+	}
+
+	return nil
+}
+
+// rolledOut reports whether all replicas of a workload run its current spec and are available.
+func rolledOut(obj client.Object) bool {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		replicas := int32(1)
+		if w.Spec.Replicas != nil {
+			replicas = *w.Spec.Replicas
+		}
+		return w.Status.ObservedGeneration >= w.Generation &&
+			w.Status.Replicas == replicas &&
+			w.Status.UpdatedReplicas == replicas &&
+			w.Status.AvailableReplicas == replicas
+	case *appsv1.DaemonSet:
+		return w.Status.ObservedGeneration >= w.Generation &&
+			w.Status.UpdatedNumberScheduled == w.Status.DesiredNumberScheduled &&
+			w.Status.NumberAvailable == w.Status.DesiredNumberScheduled
+	case *appsv1.StatefulSet:
+		replicas := int32(1)
+		if w.Spec.Replicas != nil {
+			replicas = *w.Spec.Replicas
+		}
+		return w.Status.ObservedGeneration >= w.Generation &&
+			w.Status.Replicas == replicas &&
+			w.Status.UpdatedReplicas == replicas &&
+			w.Status.AvailableReplicas == replicas
+	case *pomonitoringv1.Prometheus:
+		for _, c := range w.Status.Conditions {
+			if c.Type == pomonitoringv1.PrometheusAvailable {
+				return c.Status == pomonitoringv1.PrometheusConditionTrue &&
+					c.ObservedGeneration >= w.Generation &&
+					w.Status.UpdatedReplicas == w.Status.Replicas
+			}
+		}
+	}
+
+	return false
+}
+
+func specOf(obj client.Object) interface{} {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		return w.Spec
+	case *appsv1.DaemonSet:
+		return w.Spec
+	case *appsv1.StatefulSet:
+		return w.Spec
+	case *pomonitoringv1.Prometheus:
+		return w.Spec
+	}
+
+	return nil
+}
+
+// withSpec returns a copy of a workload with its spec replaced by the given one.
+func withSpec(obj client.Object, spec []byte) (client.Object, error) {
+	var err error
+	switch w := obj.DeepCopyObject().(type) {
+	case *appsv1.Deployment:
+		w.Spec = appsv1.DeploymentSpec{}
+		err = json.Unmarshal(spec, &w.Spec)
+		return w, err
+	case *appsv1.DaemonSet:
+		w.Spec = appsv1.DaemonSetSpec{}
+		err = json.Unmarshal(spec, &w.Spec)
+		return w, err
+	case *appsv1.StatefulSet:
+		w.Spec = appsv1.StatefulSetSpec{}
+		err = json.Unmarshal(spec, &w.Spec)
+		return w, err
+	case *pomonitoringv1.Prometheus:
+		w.Spec = pomonitoringv1.PrometheusSpec{}
+		err = json.Unmarshal(spec, &w.Spec)
+		return w, err
+	}
+
+	return nil, fmt.Errorf("rollouts of %T aren't tracked", obj)
+}
+
+// specHash identifies the spec of a desired workload.
+func specHash(obj client.Object) (string, error) {
+	raw, err := json.Marshal(specOf(obj))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:8]), nil
+}
@@ -17,20 +17,26 @@ limitations under the License.
 package controllers
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -50,9 +56,15 @@ func TestAPIs(t *testing.T) {
 var _ = BeforeSuite(func() {
 	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
 
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		// Specs that don't need an API server still run, the others fail, see requireEnvtest.
+		return
+	}
+
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
 		CRDDirectoryPaths:     []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDs:                  operatorCRDs(),
 		ErrorIfCRDPathMissing: true,
 	}
 
@@ -62,7 +74,10 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
-	err = monitoringv1alpha1.AddToScheme(scheme.Scheme)
+	err = monitoringv1beta1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = pomonitoringv1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	//+kubebuilder:scaffold:scheme
@@ -74,7 +89,57 @@ var _ = BeforeSuite(func() {
 })
 
 var _ = AfterSuite(func() {
+	if testEnv == nil {
+		return
+	}
+
 	By("tearing down the test environment")
 	err := testEnv.Stop()
 	Expect(err).NotTo(HaveOccurred())
 })
+
+// requireEnvtest fails the current spec when there's no envtest API server to run it against. Specs that
+// need one are labeled envtest, `make test-unit` leaves them out.
+func requireEnvtest() {
+	if testEnv == nil {
+		Fail("KUBEBUILDER_ASSETS isn't set: run the tests with `make test` to use envtest, or leave out the " +
+			"specs that need it with `make test-unit`")
+	}
+}
+
+// operatorCRDs returns stand-ins for the prometheus-operator CRDs the controller depends on. They serve
+// the APIs without validating the objects, which is all the tests need.
+func operatorCRDs() []*apiextensionsv1.CustomResourceDefinition {
+	var crds []*apiextensionsv1.CustomResourceDefinition
+	for _, crd := range requiredCRDs {
+		plural, _, _ := strings.Cut(crd.Name, ".")
+		crds = append(crds, &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: crd.Name},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: crd.GVK.Group,
+				Names: apiextensionsv1.CustomResourceDefinitionNames{
+					Plural:   plural,
+					Kind:     crd.GVK.Kind,
+					ListKind: crd.GVK.Kind + "List",
+				},
+				Scope: apiextensionsv1.NamespaceScoped,
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+					Name:    crd.GVK.Version,
+					Served:  true,
+					Storage: true,
+					Schema: &apiextensionsv1.CustomResourceValidation{
+						OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+							Type:                   "object",
+							XPreserveUnknownFields: pointer.Bool(true),
+						},
+					},
+					Subresources: &apiextensionsv1.CustomResourceSubresources{
+						Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+					},
+				}},
+			},
+		})
+	}
+
+	return crds
+}
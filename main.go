@@ -19,22 +19,27 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/klog/v2"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
-	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 	"github.com/gitpod-io/monitoring-cell/controllers"
 	pomonitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	//+kubebuilder:scaffold:imports
@@ -54,6 +59,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(monitoringv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(monitoringv1beta1.AddToScheme(scheme))
 
 	utilruntime.Must(pomonitoringv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
@@ -63,11 +69,20 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var dryRun bool
+	var stallTimeout time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Compute the changes to every Cell's objects with server-side dry-run and report them as events "+
+			"and in the Cell status, without applying them. Single Cells can opt in with the "+
+			monitoringv1beta1.DryRunAnnotation+" annotation.")
+	flag.DurationVar(&stallTimeout, "reconcile-stall-timeout", 10*time.Minute,
+		"Fail the liveness check when Cells are queued but no reconcile made progress for this long, "+
+			"or a single reconcile runs for longer. Zero disables the check.")
 	flag.Parse()
 
 	ctrl.SetLogger(klog.NewKlogr())
@@ -79,6 +94,14 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "df52ba6a.gitpod.io",
+		// Secrets are only watched for rotated remote-write credentials. Only the metadata of labelled ones is
+		// cached, and their values are always read from the API server.
+		NewCache: cache.BuilderWithOptions(cache.Options{
+			SelectorsByObject: cache.SelectorsByObject{
+				&corev1.Secret{}: {Label: labels.SelectorFromSet(labels.Set{monitoringv1beta1.CredentialsLabel: "true"})},
+			},
+		}),
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -101,22 +124,34 @@ func main() {
 		setupLog.Error(err, "unable to create pod REST client")
 	}
 
-	if err = (&controllers.CellReconciler{
+	reconciler := &controllers.CellReconciler{
 		Client:        mgr.GetClient(),
 		PodRESTClient: podRESTClient,
+		APIReader:     mgr.GetAPIReader(),
 		RESTConfig:    mgr.GetConfig(),
 		Scheme:        mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+		Recorder:      mgr.GetEventRecorderFor("cell-controller"),
+		DryRun:        dryRun,
+		StallTimeout:  stallTimeout,
+	}
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cell")
 		os.Exit(1)
 	}
+	// The conversion webhook needs serving certificates, which aren't around when running the controller locally.
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&monitoringv1beta1.Cell{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Cell")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
-	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+	if err := mgr.AddHealthzCheck("healthz", reconciler.LivenessCheck); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddReadyzCheck("readyz", controllers.ReadyzCheck(mgr)); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
@@ -0,0 +1,116 @@
+package components
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/gitpod"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/kubernetes"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/networkpolicy"
+	nodeexporter "github.com/gitpod-io/monitoring-cell/pkg/components/node-exporter"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/prometheus"
+	prometheusoperator "github.com/gitpod-io/monitoring-cell/pkg/components/prometheus-operator"
+)
+
+// Objects returns every object that makes up a monitoring cell, in the order they're reconciled.
+func Objects(cell *monitoringv1beta1.Cell) []client.Object {
+	var objects []client.Object
+	objects = append(objects, NetworkPolicies(cell)...)
+	objects = append(objects, PrometheusOperator(cell)...)
+	objects = append(objects, Prometheus(cell)...)
+	objects = append(objects, GitpodMonitoring(cell)...)
+	objects = append(objects, Exporters(cell)...)
+
+	return objects
+}
+
+func NetworkPolicies(cell *monitoringv1beta1.Cell) []client.Object {
+	var objects []client.Object
+	for _, np := range networkpolicy.NetworkPolicies(cell) {
+		objects = append(objects, np)
+	}
+
+	return objects
+}
+
+func PrometheusOperator(cell *monitoringv1beta1.Cell) []client.Object {
+	return []client.Object{
+		prometheusoperator.ClusterRole(cell),
+		prometheusoperator.ClusterRoleBinding(cell),
+		prometheusoperator.ServiceAccount(cell),
+		prometheusoperator.Service(cell),
+		prometheusoperator.Deployment(cell),
+		prometheusoperator.ServiceMonitor(cell),
+	}
+}
+
+func Prometheus(cell *monitoringv1beta1.Cell) []client.Object {
+	objects := []client.Object{
+		prometheus.ClusterRole(cell),
+		prometheus.ClusterRoleBinding(cell),
+	}
+	for _, role := range prometheus.Roles(cell) {
+		objects = append(objects, role)
+	}
+	for _, roleBinding := range prometheus.RoleBindings(cell) {
+		objects = append(objects, roleBinding)
+	}
+
+	return append(objects,
+		prometheus.ServiceAccount(cell),
+		prometheus.Service(cell),
+		prometheus.ServiceMonitor(cell),
+		prometheus.Prometheus(cell),
+	)
+}
+
+func GitpodMonitoring(cell *monitoringv1beta1.Cell) []client.Object {
+	var objects []client.Object
+	for _, np := range gitpod.NetworkPolicies(cell) {
+		objects = append(objects, np)
+	}
+	for _, svc := range gitpod.Services(cell) {
+		objects = append(objects, svc)
+	}
+	for _, sm := range gitpod.ServiceMonitors(cell) {
+		objects = append(objects, sm)
+	}
+
+	return objects
+}
+
+func Exporters(cell *monitoringv1beta1.Cell) []client.Object {
+	objects := []client.Object{
+		nodeexporter.ClusterRole(cell),
+		kubestatemetrics.ClusterRole(cell),
+		nodeexporter.ClusterRoleBinding(cell),
+		kubestatemetrics.ClusterRoleBinding(cell),
+		nodeexporter.ServiceAccount(cell),
+		kubestatemetrics.ServiceAccount(cell),
+		nodeexporter.Service(cell),
+		kubestatemetrics.Service(cell),
+	}
+	if kubestatemetrics.Shards(cell) > 1 {
+		objects = append(objects, kubestatemetrics.StatefulSet(cell))
+	} else {
+		objects = append(objects, kubestatemetrics.Deployment(cell))
+	}
+	if cm := kubestatemetrics.ConfigMap(cell); cm != nil {
+		objects = append(objects, cm)
+	}
+	for _, ds := range nodeexporter.Daemonsets(cell) {
+		objects = append(objects, ds)
+	}
+	for _, svc := range kubernetes.Services(cell) {
+		objects = append(objects, svc)
+	}
+	for _, sm := range kubernetes.ServiceMonitors(cell) {
+		objects = append(objects, sm)
+	}
+
+	return append(objects,
+		nodeexporter.ServiceMonitor(cell),
+		kubestatemetrics.ServiceMonitor(cell),
+	)
+}
@@ -2,8 +2,15 @@ package gitpod
 
 const (
 	App = "gitpod"
+
+	metricsPort           = 9500
+	messagebusMetricsPort = 9419
+	caddyMetricsPort      = 8003
 )
 
+// MetricsPorts are the ports Prometheus scrapes the Gitpod components on
+var MetricsPorts = []int32{metricsPort, messagebusMetricsPort, caddyMetricsPort}
+
 var (
 	matchLabels = map[string]string{
 		"app.kubernetes.io/component": "prometheus",
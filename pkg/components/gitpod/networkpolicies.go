@@ -3,13 +3,15 @@ package gitpod
 import (
 	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
 	networkv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 )
 
-func NetworkPolicies(cell *monitoringv1alpha1.Cell) []*networkv1.NetworkPolicy {
+func NetworkPolicies(cell *monitoringv1beta1.Cell) []*networkv1.NetworkPolicy {
 	var networkPolicies []*networkv1.NetworkPolicy
 	for _, target := range targets {
 		networkPolicies = append(networkPolicies, &networkv1.NetworkPolicy{
@@ -19,7 +21,7 @@ func NetworkPolicies(cell *monitoringv1alpha1.Cell) []*networkv1.NetworkPolicy {
 			},
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      fmt.Sprintf("%s-allow-prometheus", target),
-				Namespace: cell.Spec.GitpodNamespace,
+				Namespace: cell.Spec.Gitpod.Namespace,
 				Labels:    labels(target),
 				OwnerReferences: []metav1.OwnerReference{
 					{
@@ -50,6 +52,7 @@ func NetworkPolicies(cell *monitoringv1alpha1.Cell) []*networkv1.NetworkPolicy {
 								},
 							},
 						},
+						Ports: ingressPorts(cell, metricsPort),
 					},
 				},
 				PolicyTypes: []networkv1.PolicyType{
@@ -64,7 +67,7 @@ func NetworkPolicies(cell *monitoringv1alpha1.Cell) []*networkv1.NetworkPolicy {
 	return networkPolicies
 }
 
-func messagebusNetworkPolicy(cell *monitoringv1alpha1.Cell) *networkv1.NetworkPolicy {
+func messagebusNetworkPolicy(cell *monitoringv1beta1.Cell) *networkv1.NetworkPolicy {
 	return &networkv1.NetworkPolicy{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "networking.k8s.io/v1",
@@ -72,7 +75,7 @@ func messagebusNetworkPolicy(cell *monitoringv1alpha1.Cell) *networkv1.NetworkPo
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-allow-prometheus", "messagebus"),
-			Namespace: cell.Spec.GitpodNamespace,
+			Namespace: cell.Spec.Gitpod.Namespace,
 			Labels:    labels("messagebus"),
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -103,6 +106,7 @@ func messagebusNetworkPolicy(cell *monitoringv1alpha1.Cell) *networkv1.NetworkPo
 							},
 						},
 					},
+					Ports: ingressPorts(cell, messagebusMetricsPort),
 				},
 			},
 			PolicyTypes: []networkv1.PolicyType{
@@ -112,7 +116,7 @@ func messagebusNetworkPolicy(cell *monitoringv1alpha1.Cell) *networkv1.NetworkPo
 	}
 }
 
-func proxyCaddyNetowrkPolicy(cell *monitoringv1alpha1.Cell) *networkv1.NetworkPolicy {
+func proxyCaddyNetowrkPolicy(cell *monitoringv1beta1.Cell) *networkv1.NetworkPolicy {
 	return &networkv1.NetworkPolicy{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "networking.k8s.io/v1",
@@ -120,7 +124,7 @@ func proxyCaddyNetowrkPolicy(cell *monitoringv1alpha1.Cell) *networkv1.NetworkPo
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-allow-prometheus", "proxy-caddy"),
-			Namespace: cell.Spec.GitpodNamespace,
+			Namespace: cell.Spec.Gitpod.Namespace,
 			Labels:    labels("proxy-caddy"),
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -151,6 +155,7 @@ func proxyCaddyNetowrkPolicy(cell *monitoringv1alpha1.Cell) *networkv1.NetworkPo
 							},
 						},
 					},
+					Ports: ingressPorts(cell, caddyMetricsPort),
 				},
 			},
 			PolicyTypes: []networkv1.PolicyType{
@@ -159,3 +164,14 @@ func proxyCaddyNetowrkPolicy(cell *monitoringv1alpha1.Cell) *networkv1.NetworkPo
 		},
 	}
 }
+
+// ingressPorts restricts Prometheus to the metrics port of a component in strict mode.
+func ingressPorts(cell *monitoringv1beta1.Cell, port int32) []networkv1.NetworkPolicyPort {
+	if cell.Spec.NetworkPolicy.Mode != monitoringv1beta1.NetworkPolicyStrict {
+		return nil
+	}
+
+	tcp := corev1.ProtocolTCP
+	metrics := intstr.FromInt(int(port))
+	return []networkv1.NetworkPolicyPort{{Protocol: &tcp, Port: &metrics}}
+}
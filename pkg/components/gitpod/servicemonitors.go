@@ -6,10 +6,10 @@ import (
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 )
 
-func ServiceMonitors(cell *monitoringv1alpha1.Cell) []*monitoringv1.ServiceMonitor {
+func ServiceMonitors(cell *monitoringv1beta1.Cell) []*monitoringv1.ServiceMonitor {
 	var serviceMonitors []*monitoringv1.ServiceMonitor
 	for _, target := range targets {
 		serviceMonitors = append(serviceMonitors, &monitoringv1.ServiceMonitor{
@@ -36,12 +36,12 @@ func ServiceMonitors(cell *monitoringv1alpha1.Cell) []*monitoringv1.ServiceMonit
 						BearerTokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token",
 						Interval:        "60s",
 						Port:            "metrics",
-						// MetricRelabelConfigs: should be build from spec.Droplist
+						// MetricRelabelConfigs: should be build from spec.Metrics.DropList
 					},
 				},
 				JobLabel: "app.kubernetes.io/component",
 				NamespaceSelector: monitoringv1.NamespaceSelector{
-					MatchNames: []string{cell.Spec.GitpodNamespace},
+					MatchNames: []string{cell.Spec.Gitpod.Namespace},
 				},
 				Selector: metav1.LabelSelector{
 					MatchLabels: labels(target),
@@ -56,7 +56,7 @@ func ServiceMonitors(cell *monitoringv1alpha1.Cell) []*monitoringv1.ServiceMonit
 	return serviceMonitors
 }
 
-func messagebusServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor {
+func messagebusServiceMonitor(cell *monitoringv1beta1.Cell) *monitoringv1.ServiceMonitor {
 	return &monitoringv1.ServiceMonitor{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.coreos.com/v1",
@@ -81,12 +81,12 @@ func messagebusServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.Servi
 					BearerTokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token",
 					Interval:        "60s",
 					Port:            "metrics",
-					// MetricRelabelConfigs: should be build from spec.Droplist
+					// MetricRelabelConfigs: should be build from spec.Metrics.DropList
 				},
 			},
 			JobLabel: "app.kubernetes.io/component",
 			NamespaceSelector: monitoringv1.NamespaceSelector{
-				MatchNames: []string{cell.Spec.GitpodNamespace},
+				MatchNames: []string{cell.Spec.Gitpod.Namespace},
 			},
 			Selector: metav1.LabelSelector{
 				MatchLabels: labels("messagebus"),
@@ -95,7 +95,7 @@ func messagebusServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.Servi
 	}
 }
 
-func proxyCaddyServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor {
+func proxyCaddyServiceMonitor(cell *monitoringv1beta1.Cell) *monitoringv1.ServiceMonitor {
 	return &monitoringv1.ServiceMonitor{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.coreos.com/v1",
@@ -120,12 +120,12 @@ func proxyCaddyServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.Servi
 					BearerTokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token",
 					Interval:        "60s",
 					Port:            "metrics",
-					// MetricRelabelConfigs: should be build from spec.Droplist
+					// MetricRelabelConfigs: should be build from spec.Metrics.DropList
 				},
 			},
 			JobLabel: "app.kubernetes.io/component",
 			NamespaceSelector: monitoringv1.NamespaceSelector{
-				MatchNames: []string{cell.Spec.GitpodNamespace},
+				MatchNames: []string{cell.Spec.Gitpod.Namespace},
 			},
 			Selector: metav1.LabelSelector{
 				MatchLabels: labels("proxy-caddy"),
@@ -3,12 +3,12 @@ package gitpod
 import (
 	"fmt"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func Services(cell *monitoringv1alpha1.Cell) []*corev1.Service {
+func Services(cell *monitoringv1beta1.Cell) []*corev1.Service {
 	var services []*corev1.Service
 	for _, target := range targets {
 		services = append(services, &corev1.Service{
@@ -18,7 +18,7 @@ func Services(cell *monitoringv1alpha1.Cell) []*corev1.Service {
 			},
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      fmt.Sprintf("%s-%s", App, target),
-				Namespace: cell.Spec.GitpodNamespace,
+				Namespace: cell.Spec.Gitpod.Namespace,
 				Labels:    labels(target),
 				OwnerReferences: []metav1.OwnerReference{
 					{
@@ -33,7 +33,7 @@ func Services(cell *monitoringv1alpha1.Cell) []*corev1.Service {
 				Ports: []corev1.ServicePort{
 					{
 						Name: "metrics",
-						Port: 9500,
+						Port: metricsPort,
 					},
 				},
 				Selector: map[string]string{
@@ -49,7 +49,7 @@ func Services(cell *monitoringv1alpha1.Cell) []*corev1.Service {
 	return services
 }
 
-func messagebusService(cell *monitoringv1alpha1.Cell) *corev1.Service {
+func messagebusService(cell *monitoringv1beta1.Cell) *corev1.Service {
 	return &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -57,7 +57,7 @@ func messagebusService(cell *monitoringv1alpha1.Cell) *corev1.Service {
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-%s", App, "messagebus"),
-			Namespace: cell.Spec.GitpodNamespace,
+			Namespace: cell.Spec.Gitpod.Namespace,
 			Labels:    labels("messagebus"),
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -72,7 +72,7 @@ func messagebusService(cell *monitoringv1alpha1.Cell) *corev1.Service {
 			Ports: []corev1.ServicePort{
 				{
 					Name: "metrics",
-					Port: 9419,
+					Port: messagebusMetricsPort,
 				},
 			},
 			Selector: map[string]string{
@@ -82,7 +82,7 @@ func messagebusService(cell *monitoringv1alpha1.Cell) *corev1.Service {
 	}
 }
 
-func proxyCaddyService(cell *monitoringv1alpha1.Cell) *corev1.Service {
+func proxyCaddyService(cell *monitoringv1beta1.Cell) *corev1.Service {
 	return &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -90,7 +90,7 @@ func proxyCaddyService(cell *monitoringv1alpha1.Cell) *corev1.Service {
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "gitpod-proxy-caddy",
-			Namespace: cell.Spec.GitpodNamespace,
+			Namespace: cell.Spec.Gitpod.Namespace,
 			Labels:    labels("proxy-caddy"),
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -105,7 +105,7 @@ func proxyCaddyService(cell *monitoringv1alpha1.Cell) *corev1.Service {
 			Ports: []corev1.ServicePort{
 				{
 					Name: "caddy-metrics",
-					Port: 8003,
+					Port: caddyMetricsPort,
 				},
 			},
 			Selector: map[string]string{
@@ -0,0 +1,216 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/provider"
+)
+
+const (
+	// Namespace is where the Kubernetes components run
+	Namespace = "kube-system"
+
+	// CellLabel and CellNamespaceLabel identify the cell the Services in kube-system belong to. They can't
+	// have the cell as owner, as owners have to be in the same namespace.
+	CellLabel          = "monitoring.gitpod.io/cell"
+	CellNamespaceLabel = "monitoring.gitpod.io/cell-namespace"
+)
+
+// Component is an optional Kubernetes component running in kube-system
+type Component struct {
+	Name string
+	// Selector matches the pods of the component
+	Selector map[string]string
+	Port     int32
+	Scheme   string
+}
+
+var (
+	Scheduler         = Component{Name: "kube-scheduler", Selector: map[string]string{"component": "kube-scheduler"}, Port: 10259, Scheme: "https"}
+	ControllerManager = Component{Name: "kube-controller-manager", Selector: map[string]string{"component": "kube-controller-manager"}, Port: 10257, Scheme: "https"}
+	Etcd              = Component{Name: "etcd", Selector: map[string]string{"component": "etcd"}, Port: 2379, Scheme: "https"}
+	CoreDNS           = Component{Name: "coredns", Selector: map[string]string{"k8s-app": "kube-dns"}, Port: 9153, Scheme: "http"}
+	KubeProxy         = Component{Name: "kube-proxy", Selector: map[string]string{"k8s-app": "kube-proxy"}, Port: 10249, Scheme: "http"}
+)
+
+// EnabledComponents returns the components the cell enables monitoring for, with the pod selectors of the
+// provider the cell runs on.
+func EnabledComponents(cell *monitoringv1beta1.Cell) []Component {
+	spec := cell.Spec.Kubernetes
+	profile := provider.For(cell)
+	toggles := []struct {
+		component Component
+		enabled   *bool
+	}{
+		{Scheduler, spec.Scheduler.Enabled},
+		{ControllerManager, spec.ControllerManager.Enabled},
+		{Etcd, spec.Etcd.Enabled},
+		{CoreDNS, spec.CoreDNS.Enabled},
+		{KubeProxy, spec.KubeProxy.Enabled},
+	}
+
+	var components []Component
+	for _, t := range toggles {
+		if t.enabled == nil || !*t.enabled {
+			continue
+		}
+		component := t.component
+		if selector, ok := profile.Selectors[component.Name]; ok {
+			component.Selector = selector
+		}
+		components = append(components, component)
+	}
+
+	return components
+}
+
+// Unavailable returns why an enabled component isn't monitored whether or not its pods run, e.g. because the
+// provider manages it. It's empty when monitoring the component only depends on finding its pods.
+func Unavailable(cell *monitoringv1beta1.Cell, component Component) string {
+	if reason, ok := provider.For(cell).Unavailable[component.Name]; ok {
+		return reason
+	}
+	if component.Name == Etcd.Name && cell.Spec.Kubernetes.Etcd.ClientCertSecret == "" {
+		return "no client certificate Secret is configured"
+	}
+
+	return ""
+}
+
+// monitoredComponents returns the enabled components the controller found running.
+func monitoredComponents(cell *monitoringv1beta1.Cell) []Component {
+	var components []Component
+	for _, component := range EnabledComponents(cell) {
+		for _, status := range cell.Status.KubernetesComponents {
+			if status.Name == component.Name && status.Monitored {
+				components = append(components, component)
+			}
+		}
+	}
+
+	return components
+}
+
+// ServiceLabels are the labels of the Service of a component.
+func ServiceLabels(cell *monitoringv1beta1.Cell, component Component) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name": component.Name,
+		CellLabel:                cell.Name,
+		CellNamespaceLabel:       cell.Namespace,
+	}
+}
+
+// Services returns a headless Service per monitored component, selecting its pods in kube-system.
+func Services(cell *monitoringv1beta1.Cell) []*corev1.Service {
+	var services []*corev1.Service
+	for _, component := range monitoredComponents(cell) {
+		services = append(services, &corev1.Service{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s-%s", component.Name, cell.Namespace, cell.Name),
+				Namespace: Namespace,
+				Labels:    ServiceLabels(cell, component),
+			},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: corev1.ClusterIPNone,
+				Selector:  component.Selector,
+				Ports: []corev1.ServicePort{
+					{
+						Name:       "metrics",
+						Port:       component.Port,
+						TargetPort: intstr.FromInt(int(component.Port)),
+					},
+				},
+			},
+		})
+	}
+
+	return services
+}
+
+func componentServiceMonitor(cell *monitoringv1beta1.Cell, component Component) *monitoringv1.ServiceMonitor {
+	c := cell.DeepCopy()
+	labels := c.Labels
+	labels["app.kubernetes.io/component"] = component.Name
+	labels["app.kubernetes.io/name"] = component.Name
+
+	endpoint := monitoringv1.Endpoint{
+		Port:     "metrics",
+		Interval: "60s",
+		Scheme:   component.Scheme,
+		RelabelConfigs: []*monitoringv1.RelabelConfig{
+			{
+				Action:       "replace",
+				SourceLabels: []monitoringv1.LabelName{"__meta_kubernetes_pod_node_name"},
+				TargetLabel:  "node",
+			},
+		},
+	}
+	switch component.Name {
+	case Etcd.Name:
+		secret := corev1.LocalObjectReference{Name: cell.Spec.Kubernetes.Etcd.ClientCertSecret}
+		endpoint.TLSConfig = &monitoringv1.TLSConfig{
+			SafeTLSConfig: monitoringv1.SafeTLSConfig{
+				CA:        monitoringv1.SecretOrConfigMap{Secret: &corev1.SecretKeySelector{LocalObjectReference: secret, Key: "ca.crt"}},
+				Cert:      monitoringv1.SecretOrConfigMap{Secret: &corev1.SecretKeySelector{LocalObjectReference: secret, Key: "tls.crt"}},
+				KeySecret: &corev1.SecretKeySelector{LocalObjectReference: secret, Key: "tls.key"},
+			},
+		}
+	case Scheduler.Name, ControllerManager.Name:
+		// Both serve their metrics with a self-signed certificate, to clients authorized for /metrics
+		endpoint.BearerTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+		endpoint.TLSConfig = &monitoringv1.TLSConfig{
+			SafeTLSConfig: monitoringv1.SafeTLSConfig{
+				InsecureSkipVerify: true,
+			},
+		}
+	}
+	if component.Name == Scheduler.Name {
+		endpoint.MetricRelabelConfigs = []*monitoringv1.RelabelConfig{
+			{
+				Action:       "drop",
+				Regex:        deprecatedSchedulerMetrics,
+				SourceLabels: []monitoringv1.LabelName{"__name__"},
+			},
+		}
+	}
+
+	return &monitoringv1.ServiceMonitor{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "monitoring.coreos.com/v1",
+			Kind:       "ServiceMonitor",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", component.Name, cell.Name),
+			Namespace: cell.Namespace,
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: cell.APIVersion,
+					Kind:       cell.Kind,
+					Name:       cell.Name,
+					UID:        cell.UID,
+				},
+			},
+		},
+		Spec: monitoringv1.ServiceMonitorSpec{
+			JobLabel: "app.kubernetes.io/name",
+			Selector: metav1.LabelSelector{
+				MatchLabels: ServiceLabels(cell, component),
+			},
+			NamespaceSelector: monitoringv1.NamespaceSelector{
+				MatchNames: []string{Namespace},
+			},
+			Endpoints: []monitoringv1.Endpoint{endpoint},
+		},
+	}
+}
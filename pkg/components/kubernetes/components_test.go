@@ -0,0 +1,101 @@
+package kubernetes
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("Kubernetes components", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "monitoring", Labels: map[string]string{}}}
+		cell.Spec.Kubernetes.Etcd.Enabled = pointer.Bool(true)
+		cell.Spec.Kubernetes.Etcd.ClientCertSecret = "etcd-client"
+		cell.Spec.Kubernetes.CoreDNS.Enabled = pointer.Bool(true)
+		cell.Spec.Kubernetes.KubeProxy.Enabled = pointer.Bool(true)
+		cell.Spec.Kubernetes.Scheduler.Enabled = pointer.Bool(false)
+	})
+
+	names := func(components []Component) []string {
+		var names []string
+		for _, c := range components {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	It("enables only the components the cell turns on", func() {
+		Expect(names(EnabledComponents(cell))).To(Equal([]string{Etcd.Name, CoreDNS.Name, KubeProxy.Name}))
+	})
+
+	It("selects pods with the selectors of the provider", func() {
+		cell.Spec.Provider = monitoringv1beta1.ProviderGKE
+		for _, c := range EnabledComponents(cell) {
+			if c.Name == KubeProxy.Name {
+				Expect(c.Selector).To(Equal(map[string]string{"component": "kube-proxy"}))
+			}
+		}
+		Expect(KubeProxy.Selector).To(Equal(map[string]string{"k8s-app": "kube-proxy"}), "the defaults must not change")
+	})
+
+	Context("once the controller found some of them running", func() {
+		BeforeEach(func() {
+			cell.Status.KubernetesComponents = []monitoringv1beta1.KubernetesComponentStatus{
+				{Name: Etcd.Name, Monitored: true},
+				{Name: CoreDNS.Name, Monitored: true},
+				{Name: KubeProxy.Name, Message: "no pods found in kube-system"},
+			}
+		})
+
+		It("creates a headless Service in kube-system for each monitored component", func() {
+			services := Services(cell)
+			Expect(services).To(HaveLen(2))
+			for _, svc := range services {
+				Expect(svc.Namespace).To(Equal(Namespace))
+				Expect(svc.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+				Expect(svc.Labels).To(HaveKeyWithValue(CellLabel, "cell"))
+				Expect(svc.Labels).To(HaveKeyWithValue(CellNamespaceLabel, "monitoring"))
+				Expect(svc.OwnerReferences).To(BeEmpty())
+			}
+			Expect(services[0].Name).To(Equal("etcd-monitoring-cell"))
+			Expect(services[0].Spec.Ports[0].Port).To(Equal(int32(2379)))
+			Expect(services[1].Spec.Selector).To(Equal(CoreDNS.Selector))
+		})
+
+		It("scrapes each monitored component besides the kubelet and the API server", func() {
+			var monitored []string
+			for _, sm := range ServiceMonitors(cell) {
+				monitored = append(monitored, sm.Name)
+			}
+			Expect(monitored).To(Equal([]string{"kubelet-cell", "apiserver-cell", "etcd-cell", "coredns-cell"}))
+		})
+
+		It("scrapes etcd with the client certificate of the cell", func() {
+			endpoint := componentServiceMonitor(cell, Etcd).Spec.Endpoints[0]
+			Expect(endpoint.Scheme).To(Equal("https"))
+			Expect(endpoint.TLSConfig.CA.Secret.Name).To(Equal("etcd-client"))
+			Expect(endpoint.TLSConfig.CA.Secret.Key).To(Equal("ca.crt"))
+			Expect(endpoint.TLSConfig.Cert.Secret.Key).To(Equal("tls.crt"))
+			Expect(endpoint.TLSConfig.KeySecret.Key).To(Equal("tls.key"))
+		})
+
+		It("selects the Service of its component only", func() {
+			sm := componentServiceMonitor(cell, CoreDNS)
+			Expect(sm.Spec.Selector.MatchLabels).To(Equal(ServiceLabels(cell, CoreDNS)))
+			Expect(sm.Spec.NamespaceSelector.MatchNames).To(Equal([]string{Namespace}))
+		})
+	})
+
+	It("drops the deprecated metrics of the scheduler", func() {
+		endpoint := componentServiceMonitor(cell, Scheduler).Spec.Endpoints[0]
+		Expect(endpoint.BearerTokenFile).NotTo(BeEmpty())
+		Expect(endpoint.MetricRelabelConfigs).To(ContainElement(HaveField("Regex", deprecatedSchedulerMetrics)))
+	})
+})
@@ -7,19 +7,27 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 )
 
-func ServiceMonitors(cell *monitoringv1alpha1.Cell) []*monitoringv1.ServiceMonitor {
+// deprecatedSchedulerMetrics are dropped wherever scheduler metrics are scraped
+const deprecatedSchedulerMetrics = "scheduler_(e2e_scheduling_latency_microseconds|scheduling_algorithm_predicate_evaluation|scheduling_algorithm_priority_evaluation|scheduling_algorithm_preemption_evaluation|scheduling_algorithm_latency_microseconds|binding_latency_microseconds|scheduling_latency_seconds)"
+
+func ServiceMonitors(cell *monitoringv1beta1.Cell) []*monitoringv1.ServiceMonitor {
 	var servicemonitors []*monitoringv1.ServiceMonitor
 
-	return append(servicemonitors,
+	servicemonitors = append(servicemonitors,
 		serviceMonitorKubelet(cell),
 		serviceMonitorAPIServer(cell),
 	)
+	for _, component := range monitoredComponents(cell) {
+		servicemonitors = append(servicemonitors, componentServiceMonitor(cell, component))
+	}
+
+	return servicemonitors
 }
 
-func serviceMonitorKubelet(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor {
+func serviceMonitorKubelet(cell *monitoringv1beta1.Cell) *monitoringv1.ServiceMonitor {
 	c := cell.DeepCopy()
 	labels := c.Labels
 	labels["app.kubernetes.io/component"] = "kubelet"
@@ -60,11 +68,7 @@ func serviceMonitorKubelet(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceM
 					Port:            "https-metrics",
 					Interval:        "60s",
 					Scheme:          "https",
-					TLSConfig: &monitoringv1.TLSConfig{
-						SafeTLSConfig: monitoringv1.SafeTLSConfig{
-							InsecureSkipVerify: true,
-						},
-					},
+					TLSConfig:       kubeletTLSConfig(cell),
 					RelabelConfigs: []*monitoringv1.RelabelConfig{
 						{
 							SourceLabels: []monitoringv1.LabelName{"__metrics_path__"},
@@ -79,7 +83,7 @@ func serviceMonitorKubelet(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceM
 						},
 						{
 							Action:       "drop",
-							Regex:        "scheduler_(e2e_scheduling_latency_microseconds|scheduling_algorithm_predicate_evaluation|scheduling_algorithm_priority_evaluation|scheduling_algorithm_preemption_evaluation|scheduling_algorithm_latency_microseconds|binding_latency_microseconds|scheduling_latency_seconds)",
+							Regex:        deprecatedSchedulerMetrics,
 							SourceLabels: []monitoringv1.LabelName{"__name__"},
 						},
 						{
@@ -123,11 +127,7 @@ func serviceMonitorKubelet(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceM
 					Path:            "/metrics/cadvisor",
 					Port:            "https-metrics",
 					Scheme:          "https",
-					TLSConfig: &monitoringv1.TLSConfig{
-						SafeTLSConfig: monitoringv1.SafeTLSConfig{
-							InsecureSkipVerify: true,
-						},
-					},
+					TLSConfig:       kubeletTLSConfig(cell),
 					RelabelConfigs: []*monitoringv1.RelabelConfig{
 						{
 							SourceLabels: []monitoringv1.LabelName{"__metrics_path__"},
@@ -172,18 +172,30 @@ func serviceMonitorKubelet(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceM
 						},
 					},
 					// MetricRelabelConfigs: common.DropMetricsRelabeling(ctx),
-					TLSConfig: &monitoringv1.TLSConfig{
-						SafeTLSConfig: monitoringv1.SafeTLSConfig{
-							InsecureSkipVerify: true,
-						},
-					},
+					TLSConfig: kubeletTLSConfig(cell),
 				},
 			},
 		},
 	}
 }
 
-func serviceMonitorAPIServer(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor {
+// kubeletTLSConfig verifies the kubelets against the cluster's CA when scrapes verify TLS. That needs the
+// kubelets to serve certificates signed by it, e.g. with serverTLSBootstrap.
+func kubeletTLSConfig(cell *monitoringv1beta1.Cell) *monitoringv1.TLSConfig {
+	if cell.Spec.ScrapeTLS.Mode == monitoringv1beta1.ScrapeTLSVerify {
+		return &monitoringv1.TLSConfig{
+			CAFile: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+		}
+	}
+
+	return &monitoringv1.TLSConfig{
+		SafeTLSConfig: monitoringv1.SafeTLSConfig{
+			InsecureSkipVerify: true,
+		},
+	}
+}
+
+func serviceMonitorAPIServer(cell *monitoringv1beta1.Cell) *monitoringv1.ServiceMonitor {
 	c := cell.DeepCopy()
 	labels := c.Labels
 	labels["app.kubernetes.io/component"] = "api-server"
@@ -238,7 +250,7 @@ func serviceMonitorAPIServer(cell *monitoringv1alpha1.Cell) *monitoringv1.Servic
 						},
 						{
 							Action:       "drop",
-							Regex:        "scheduler_(e2e_scheduling_latency_microseconds|scheduling_algorithm_predicate_evaluation|scheduling_algorithm_priority_evaluation|scheduling_algorithm_preemption_evaluation|scheduling_algorithm_latency_microseconds|binding_latency_microseconds|scheduling_latency_seconds)",
+							Regex:        deprecatedSchedulerMetrics,
 							SourceLabels: []monitoringv1.LabelName{"__name__"},
 						},
 						{
@@ -0,0 +1,35 @@
+package kubernetes
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+var _ = Describe("Kubelet and API server scrape TLS", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "monitoring", Labels: map[string]string{}}}
+	})
+
+	It("skips verifying the kubelets' certificates by default", func() {
+		for _, endpoint := range serviceMonitorKubelet(cell).Spec.Endpoints {
+			Expect(endpoint.TLSConfig.InsecureSkipVerify).To(BeTrue())
+			Expect(endpoint.TLSConfig.CAFile).To(BeEmpty())
+		}
+	})
+
+	It("verifies the kubelets and the API server against the cluster's CA when scrapes verify TLS", func() {
+		cell.Spec.ScrapeTLS.Mode = monitoringv1beta1.ScrapeTLSVerify
+		endpoints := append(serviceMonitorKubelet(cell).Spec.Endpoints, serviceMonitorAPIServer(cell).Spec.Endpoints...)
+		Expect(endpoints).NotTo(BeEmpty())
+		for _, endpoint := range endpoints {
+			Expect(endpoint.TLSConfig.InsecureSkipVerify).To(BeFalse())
+			Expect(endpoint.TLSConfig.CAFile).To(Equal("/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"))
+		}
+	})
+})
@@ -6,7 +6,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 )
 
 var (
@@ -16,8 +16,8 @@ var (
 	}
 )
 
-func ClusterRole(cell *monitoringv1alpha1.Cell) *rbacv1.ClusterRole {
-	return &rbacv1.ClusterRole{
+func ClusterRole(cell *monitoringv1beta1.Cell) *rbacv1.ClusterRole {
+	clusterRole := &rbacv1.ClusterRole{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "rbac.authorization.k8s.io/v1",
 			Kind:       "ClusterRole",
@@ -142,4 +142,22 @@ func ClusterRole(cell *monitoringv1alpha1.Cell) *rbacv1.ClusterRole {
 			},
 		},
 	}
+	clusterRole.Rules = append(clusterRole.Rules, customResourceRules(cell)...)
+	if Shards(cell) > 1 {
+		// Shards look up their own pod and StatefulSet to find out which objects they're responsible for
+		clusterRole.Rules = append(clusterRole.Rules,
+			rbacv1.PolicyRule{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get"},
+			},
+			rbacv1.PolicyRule{
+				APIGroups: []string{"apps"},
+				Resources: []string{"statefulsets"},
+				Verbs:     []string{"get"},
+			},
+		)
+	}
+
+	return clusterRole
 }
@@ -0,0 +1,170 @@
+package kubestatemetrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+// CustomResource is a resource listed in a custom resource state config
+type CustomResource struct {
+	Group          string
+	Version        string
+	Kind           string
+	ResourcePlural string
+}
+
+// ConfigMap holds the custom resource state config of kube-state-metrics. It's nil if the cell has none.
+func ConfigMap(cell *monitoringv1beta1.Cell) *corev1.ConfigMap {
+	config := customResourceState(cell)
+	if config == nil {
+		return nil
+	}
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", Name, cell.Name),
+			Namespace: cell.Namespace,
+			Labels:    Labels(cell),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: cell.APIVersion,
+					Kind:       cell.Kind,
+					Name:       cell.Name,
+					UID:        cell.UID,
+				},
+			},
+		},
+		// JSON is valid YAML
+		Data: map[string]string{customResourceStateFile: string(config)},
+	}
+}
+
+// CustomResources lists the resources of the custom resource state config of the cell.
+func CustomResources(cell *monitoringv1beta1.Cell) []CustomResource {
+	config := cell.Spec.KubeStateMetrics.CustomResourceState
+	if config == nil || len(config.Raw) == 0 {
+		return nil
+	}
+
+	var crs struct {
+		Spec struct {
+			Resources []struct {
+				GroupVersionKind struct {
+					Group   string `json:"group"`
+					Version string `json:"version"`
+					Kind    string `json:"kind"`
+				} `json:"groupVersionKind"`
+				ResourcePlural string `json:"resourcePlural"`
+			} `json:"resources"`
+		} `json:"spec"`
+	}
+	// A config kube-state-metrics can't parse makes it fail, there's nothing to grant.
+	if err := json.Unmarshal(config.Raw, &crs); err != nil {
+		return nil
+	}
+
+	var resources []CustomResource
+	for _, r := range crs.Spec.Resources {
+		gvk := r.GroupVersionKind
+		if gvk.Kind == "" {
+			continue
+		}
+		resources = append(resources, CustomResource{
+			Group:          gvk.Group,
+			Version:        gvk.Version,
+			Kind:           gvk.Kind,
+			ResourcePlural: r.ResourcePlural,
+		})
+	}
+
+	return resources
+}
+
+// BuiltinGroup reports whether an API group is one of Kubernetes' own. Groups of CRDs contain a dot, and
+// those ending in .k8s.io are reserved for Kubernetes.
+func BuiltinGroup(group string) bool {
+	return !strings.Contains(group, ".") || strings.HasSuffix(group, ".k8s.io")
+}
+
+// customResourceState returns the custom resource state config of the cell, nil if it has none. kube-state-metrics
+// derives plurals by appending an s to the kind, so resources without one get the plural the API server serves.
+func customResourceState(cell *monitoringv1beta1.Cell) []byte {
+	config := cell.Spec.KubeStateMetrics.CustomResourceState
+	if config == nil || len(config.Raw) == 0 {
+		return nil
+	}
+
+	var crs map[string]interface{}
+	if err := json.Unmarshal(config.Raw, &crs); err != nil {
+		return config.Raw
+	}
+	spec, _ := crs["spec"].(map[string]interface{})
+	resources, _ := spec["resources"].([]interface{})
+	for _, r := range resources {
+		resource, _ := r.(map[string]interface{})
+		gvk, _ := resource["groupVersionKind"].(map[string]interface{})
+		if resource == nil || gvk == nil || resource["resourcePlural"] != nil {
+			continue
+		}
+		group, _ := gvk["group"].(string)
+		kind, _ := gvk["kind"].(string)
+		if status := findCustomResource(cell, group, kind); status != nil && status.Resource != "" {
+			resource["resourcePlural"] = status.Resource
+		}
+	}
+	raw, err := json.Marshal(crs)
+	if err != nil {
+		return config.Raw
+	}
+
+	return raw
+}
+
+// customResourceStateHash identifies the custom resource state config, so kube-state-metrics is restarted when it changes.
+func customResourceStateHash(cell *monitoringv1beta1.Cell) string {
+	sum := sha256.Sum256(customResourceState(cell))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// customResourceRules grants access to the resources of the custom resource state config the controller found
+// to be served custom resources, see cell.Status.CustomResources.
+func customResourceRules(cell *monitoringv1beta1.Cell) []rbacv1.PolicyRule {
+	var rules []rbacv1.PolicyRule
+	for _, r := range CustomResources(cell) {
+		status := findCustomResource(cell, r.Group, r.Kind)
+		if status == nil || !status.Granted {
+			continue
+		}
+		rules = append(rules, rbacv1.PolicyRule{
+			APIGroups: []string{status.Group},
+			Resources: []string{status.Resource},
+			Verbs:     listWatchRBAC,
+		})
+	}
+
+	return rules
+}
+
+func findCustomResource(cell *monitoringv1beta1.Cell, group, kind string) *monitoringv1beta1.CustomResourceStatus {
+	for i := range cell.Status.CustomResources {
+		if cell.Status.CustomResources[i].Group == group && cell.Status.CustomResources[i].Kind == kind {
+			return &cell.Status.CustomResources[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package kubestatemetrics_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+)
+
+const customResourceStateConfig = `{
+	"kind": "CustomResourceStateMetrics",
+	"spec": {
+		"resources": [
+			{"groupVersionKind": {"group": "workspace.gitpod.io", "version": "v1", "kind": "Workspace"}},
+			{"groupVersionKind": {"group": "policy.example.com", "version": "v1", "kind": "Policy"}, "resourcePlural": "policies"},
+			{"groupVersionKind": {"group": "", "version": "v1", "kind": "Secret"}}
+		]
+	}
+}`
+
+var _ = Describe("Custom resource state", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{}
+		cell.Name = "cell"
+		cell.Labels = map[string]string{}
+		cell.Spec.KubeStateMetrics.CustomResourceState = &runtime.RawExtension{Raw: []byte(customResourceStateConfig)}
+		cell.Status.CustomResources = []monitoringv1beta1.CustomResourceStatus{
+			{Group: "workspace.gitpod.io", Kind: "Workspace", Resource: "workspaces", Granted: true},
+			{Group: "policy.example.com", Kind: "Policy", Resource: "policies", Granted: true},
+			{Group: "", Kind: "Secret", Message: "resources of built-in API groups can't be granted"},
+		}
+	})
+
+	It("lists the resources of the config", func() {
+		Expect(kubestatemetrics.CustomResources(cell)).To(Equal([]kubestatemetrics.CustomResource{
+			{Group: "workspace.gitpod.io", Version: "v1", Kind: "Workspace"},
+			{Group: "policy.example.com", Version: "v1", Kind: "Policy", ResourcePlural: "policies"},
+			{Version: "v1", Kind: "Secret"},
+		}))
+	})
+
+	It("grants access only to the resources the controller found to be served custom resources", func() {
+		builtin := kubestatemetrics.ClusterRole(&monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}).Rules
+		rules := kubestatemetrics.ClusterRole(cell).Rules
+		Expect(rules).To(HaveLen(len(builtin) + 2))
+		Expect(rules).To(ContainElements(
+			rbacv1.PolicyRule{APIGroups: []string{"workspace.gitpod.io"}, Resources: []string{"workspaces"}, Verbs: []string{"list", "watch"}},
+			rbacv1.PolicyRule{APIGroups: []string{"policy.example.com"}, Resources: []string{"policies"}, Verbs: []string{"list", "watch"}},
+		))
+	})
+
+	It("grants nothing before the controller looked up the resources", func() {
+		cell.Status.CustomResources = nil
+		Expect(kubestatemetrics.ClusterRole(cell).Rules).NotTo(ContainElement(HaveField("APIGroups", ContainElement("workspace.gitpod.io"))))
+	})
+
+	It("fills in the served plural of resources that don't give one", func() {
+		var config struct {
+			Spec struct {
+				Resources []struct {
+					ResourcePlural string `json:"resourcePlural"`
+				} `json:"resources"`
+			} `json:"spec"`
+		}
+		Expect(json.Unmarshal([]byte(kubestatemetrics.ConfigMap(cell).Data["custom-resource-state.yaml"]), &config)).To(Succeed())
+		Expect(config.Spec.Resources).To(HaveLen(3))
+		Expect(config.Spec.Resources[0].ResourcePlural).To(Equal("workspaces"))
+		Expect(config.Spec.Resources[1].ResourcePlural).To(Equal("policies"))
+		Expect(config.Spec.Resources[2].ResourcePlural).To(BeEmpty())
+	})
+
+	It("renders no ConfigMap without a config", func() {
+		cell.Spec.KubeStateMetrics.CustomResourceState = nil
+		Expect(kubestatemetrics.ConfigMap(cell)).To(BeNil())
+	})
+})
@@ -1,6 +1,6 @@
 package kubestatemetrics
 
-import monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+import monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 
 const (
 	Name        = "kube-state-metrics"
@@ -8,9 +8,15 @@ const (
 	ImageURL    = "k8s.gcr.io/kube-state-metrics/kube-state-metrics"
 	rbacURL     = "quay.io/brancz/kube-rbac-proxy"
 	rbacVersion = "0.13.0"
+
+	// CustomResourceStateHashAnnotation on the kube-state-metrics pods changes whenever the custom resource state config does
+	CustomResourceStateHashAnnotation = "monitoring.gitpod.io/custom-resource-state-hash"
+
+	customResourceStateDirectory = "/etc/kube-state-metrics"
+	customResourceStateFile      = "custom-resource-state.yaml"
 )
 
-func Labels(cell *monitoringv1alpha1.Cell) map[string]string {
+func Labels(cell *monitoringv1beta1.Cell) map[string]string {
 	c := cell.DeepCopy()
 	labels := c.Labels
 	labels["app.kubernetes.io/name"] = Name
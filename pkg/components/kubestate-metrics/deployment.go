@@ -2,6 +2,7 @@ package kubestatemetrics
 
 import (
 	"fmt"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -9,7 +10,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/scrapetls"
+	"github.com/gitpod-io/monitoring-cell/pkg/provider"
 )
 
 func rbacProxyContainerSpec(portName string, portNumber, listenAddress int32) corev1.Container {
@@ -47,7 +50,7 @@ func rbacProxyContainerSpec(portName string, portNumber, listenAddress int32) co
 	}
 }
 
-func Deployment(cell *monitoringv1alpha1.Cell) *appsv1.Deployment {
+func Deployment(cell *monitoringv1beta1.Cell) *appsv1.Deployment {
 	return &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
@@ -69,46 +72,132 @@ func Deployment(cell *monitoringv1alpha1.Cell) *appsv1.Deployment {
 		Spec: appsv1.DeploymentSpec{
 			Selector: &metav1.LabelSelector{MatchLabels: Labels(cell)},
 			Replicas: pointer.Int32(1),
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: Labels(cell),
-					Annotations: map[string]string{
-						"kubectl.kubernetes.io/default-container": "kube-state-metrics",
-					},
-				},
-				Spec: corev1.PodSpec{
-					ServiceAccountName:           fmt.Sprintf("%s-%s", Name, cell.Name),
-					AutomountServiceAccountToken: pointer.Bool(true),
-					// NodeSelector:                 ctx.Config.NodeSelector,
-					Containers: []corev1.Container{
-						{
-							Name:  Name,
-							Image: fmt.Sprintf("%s:v%s", ImageURL, Version),
-							Args: []string{
-								"--host=127.0.0.1",
-								"--port=8081",
-								"--telemetry-host=127.0.0.1",
-								"--telemetry-port=8082",
-								"--metric-labels-allowlist=nodes=[cloud.google.com/gke-nodepool,topology.kubernetes.io/region],pods=[component,workspaceType,owner,metaID]",
-							},
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									"cpu":    resource.MustParse("10m"),
-									"memory": resource.MustParse("190Mi"),
-								},
-							},
-							SecurityContext: &corev1.SecurityContext{
-								AllowPrivilegeEscalation: pointer.Bool(false),
-								Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
-								ReadOnlyRootFilesystem:   pointer.Bool(true),
-								RunAsUser:                pointer.Int64(65534),
-							},
+			Template: podTemplate(cell),
+		},
+	}
+}
+
+// podTemplate is shared by the Deployment and the StatefulSet kube-state-metrics runs as.
+func podTemplate(cell *monitoringv1beta1.Cell) corev1.PodTemplateSpec {
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: Labels(cell),
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/default-container": "kube-state-metrics",
+			},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName:           fmt.Sprintf("%s-%s", Name, cell.Name),
+			AutomountServiceAccountToken: pointer.Bool(true),
+			// NodeSelector:                 ctx.Config.NodeSelector,
+			Containers: []corev1.Container{
+				{
+					Name:  Name,
+					Image: fmt.Sprintf("%s:v%s", ImageURL, Version),
+					Args:  args(cell),
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							"cpu":    resource.MustParse("10m"),
+							"memory": resource.MustParse("190Mi"),
 						},
-						rbacProxyContainerSpec("main", 8081, 8443),
-						rbacProxyContainerSpec("self", 8082, 9443),
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: pointer.Bool(false),
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+						ReadOnlyRootFilesystem:   pointer.Bool(true),
+						RunAsUser:                pointer.Int64(65534),
 					},
 				},
+				rbacProxyContainerSpec("main", 8081, 8443),
+				rbacProxyContainerSpec("self", 8082, 9443),
 			},
 		},
 	}
+	withCustomResourceState(cell, &template)
+	// Both kube-rbac-proxy sidecars serve with the certificate
+	scrapetls.WithServingCertificate(cell, ServingCertificate(cell), &template.Spec, func(c corev1.Container) bool {
+		return strings.HasPrefix(c.Name, "kube-rbac-proxy")
+	})
+
+	return template
+}
+
+// withCustomResourceState mounts the custom resource state config of the cell, if it has one, into kube-state-metrics.
+func withCustomResourceState(cell *monitoringv1beta1.Cell, template *corev1.PodTemplateSpec) {
+	configMap := ConfigMap(cell)
+	if configMap == nil {
+		return
+	}
+
+	template.Annotations[CustomResourceStateHashAnnotation] = customResourceStateHash(cell)
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: "custom-resource-state",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+			},
+		},
+	})
+	container := &template.Spec.Containers[0]
+	container.Args = append(container.Args, fmt.Sprintf("--custom-resource-state-config-file=%s/%s", customResourceStateDirectory, customResourceStateFile))
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "custom-resource-state",
+		MountPath: customResourceStateDirectory,
+		ReadOnly:  true,
+	})
+}
+
+// defaultMetricLabelsAllowlist exposes the node labels the provider names node pools and regions with, and
+// the labels Gitpod puts on workspace pods
+func defaultMetricLabelsAllowlist(cell *monitoringv1beta1.Cell) []monitoringv1beta1.ResourceKeys {
+	return []monitoringv1beta1.ResourceKeys{
+		{Resource: "nodes", Keys: provider.For(cell).NodeLabels()},
+		{Resource: "pods", Keys: []string{"component", "workspaceType", "owner", "metaID"}},
+	}
+}
+
+func args(cell *monitoringv1beta1.Cell) []string {
+	spec := cell.Spec.KubeStateMetrics
+	args := []string{
+		"--host=127.0.0.1",
+		"--port=8081",
+		"--telemetry-host=127.0.0.1",
+		"--telemetry-port=8082",
+	}
+
+	if len(spec.Resources) > 0 {
+		resources := make([]string, len(spec.Resources))
+		for i, r := range spec.Resources {
+			resources[i] = string(r)
+		}
+		args = append(args, "--resources="+strings.Join(resources, ","))
+	}
+
+	labels := spec.MetricLabelsAllowlist
+	if labels == nil {
+		labels = defaultMetricLabelsAllowlist(cell)
+	}
+	args = append(args, "--metric-labels-allowlist="+resourceKeys(labels))
+	if len(spec.MetricAnnotationsAllowlist) > 0 {
+		args = append(args, "--metric-annotations-allowlist="+resourceKeys(spec.MetricAnnotationsAllowlist))
+	}
+
+	if len(spec.MetricAllowlist) > 0 {
+		args = append(args, "--metric-allowlist="+strings.Join(spec.MetricAllowlist, ","))
+	}
+	if len(spec.MetricDenylist) > 0 {
+		args = append(args, "--metric-denylist="+strings.Join(spec.MetricDenylist, ","))
+	}
+
+	return args
+}
+
+// resourceKeys formats keys the way kube-state-metrics expects them, e.g. nodes=[a,b],pods=[c]
+func resourceKeys(keys []monitoringv1beta1.ResourceKeys) string {
+	formatted := make([]string, len(keys))
+	for i, k := range keys {
+		formatted[i] = fmt.Sprintf("%s=[%s]", k.Resource, strings.Join(k.Keys, ","))
+	}
+
+	return strings.Join(formatted, ",")
 }
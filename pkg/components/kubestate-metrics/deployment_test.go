@@ -0,0 +1,74 @@
+package kubestatemetrics_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+)
+
+var _ = Describe("kube-state-metrics args", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Labels: map[string]string{}}}
+	})
+
+	args := func() []string {
+		return kubestatemetrics.Deployment(cell).Spec.Template.Spec.Containers[0].Args
+	}
+
+	It("exposes the node labels of the provider and Gitpod's pod labels by default", func() {
+		cell.Spec.Provider = monitoringv1beta1.ProviderGKE
+		Expect(args()).To(ContainElement("--metric-labels-allowlist=nodes=[cloud.google.com/gke-nodepool,topology.kubernetes.io/region],pods=[component,workspaceType,owner,metaID]"))
+	})
+
+	It("exposes only the region of nodes on the generic provider", func() {
+		Expect(args()).To(ContainElement("--metric-labels-allowlist=nodes=[topology.kubernetes.io/region],pods=[component,workspaceType,owner,metaID]"))
+	})
+
+	It("leaves out the flags the cell doesn't set", func() {
+		Expect(args()).To(Equal([]string{
+			"--host=127.0.0.1",
+			"--port=8081",
+			"--telemetry-host=127.0.0.1",
+			"--telemetry-port=8082",
+			"--metric-labels-allowlist=nodes=[topology.kubernetes.io/region],pods=[component,workspaceType,owner,metaID]",
+		}))
+	})
+
+	It("passes the resources, allowlists and denylist of the cell", func() {
+		cell.Spec.KubeStateMetrics = monitoringv1beta1.KubeStateMetricsSpec{
+			Resources: []monitoringv1beta1.KubeStateMetricsResource{"pods", "nodes"},
+			MetricLabelsAllowlist: []monitoringv1beta1.ResourceKeys{
+				{Resource: "namespaces", Keys: []string{"team"}},
+				{Resource: "pods", Keys: []string{"*"}},
+			},
+			MetricAnnotationsAllowlist: []monitoringv1beta1.ResourceKeys{
+				{Resource: "pods", Keys: []string{"gitpod.io/ownerToken"}},
+			},
+			MetricDenylist: []string{"kube_pod_status_.*", "kube_node_info"},
+		}
+		Expect(args()).To(ContainElements(
+			"--resources=pods,nodes",
+			"--metric-labels-allowlist=namespaces=[team],pods=[*]",
+			"--metric-annotations-allowlist=pods=[gitpod.io/ownerToken]",
+			"--metric-denylist=kube_pod_status_.*,kube_node_info",
+		))
+		Expect(args()).NotTo(ContainElement(HavePrefix("--metric-allowlist")))
+	})
+
+	It("passes the metric allowlist of the cell", func() {
+		cell.Spec.KubeStateMetrics.MetricAllowlist = []string{"kube_pod_info", "kube_node_.*"}
+		Expect(args()).To(ContainElement("--metric-allowlist=kube_pod_info,kube_node_.*"))
+		Expect(args()).NotTo(ContainElement(HavePrefix("--metric-denylist")))
+	})
+
+	It("exposes no labels when the cell's allowlist is empty rather than unset", func() {
+		cell.Spec.KubeStateMetrics.MetricLabelsAllowlist = []monitoringv1beta1.ResourceKeys{}
+		Expect(args()).To(ContainElement("--metric-labels-allowlist="))
+	})
+})
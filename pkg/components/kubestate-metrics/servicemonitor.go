@@ -2,13 +2,19 @@ package kubestatemetrics
 
 import (
 	"fmt"
+	"regexp"
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/scrapetls"
+	"github.com/gitpod-io/monitoring-cell/pkg/provider"
 )
 
+// nonAlphanumeric matches the characters kube-state-metrics replaces in the names of label metrics
+var nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
 type replaceLabel struct {
 	Source string
 	Target string
@@ -32,12 +38,15 @@ func labelsReplaceAndDrop(replaceLabels []replaceLabel) []*monitoringv1.RelabelC
 	return configs
 }
 
-func ServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor {
-	configs := labelsReplaceAndDrop([]replaceLabel{
-		{
-			Source: "label_cloud_google_com_gke_nodepool",
+func ServiceMonitor(cell *monitoringv1beta1.Cell) *monitoringv1.ServiceMonitor {
+	var replaceLabels []replaceLabel
+	if label := provider.For(cell).NodePoolLabel; label != "" {
+		replaceLabels = append(replaceLabels, replaceLabel{
+			Source: "label_" + nonAlphanumeric.ReplaceAllString(label, "_"),
 			Target: "nodepool",
-		},
+		})
+	}
+	configs := labelsReplaceAndDrop(append(replaceLabels, []replaceLabel{
 		{
 			Source: "label_topology_kubernetes_io_region",
 			Target: "region",
@@ -58,7 +67,7 @@ func ServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor
 			Source: "label_meta_id",
 			Target: "metaID",
 		},
-	})
+	}...))
 
 	return &monitoringv1.ServiceMonitor{
 		TypeMeta: metav1.TypeMeta{
@@ -87,30 +96,17 @@ func ServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor
 					ScrapeTimeout:   "30s",
 					Scheme:          "https",
 					HonorLabels:     true,
-					TLSConfig: &monitoringv1.TLSConfig{
-						SafeTLSConfig: monitoringv1.SafeTLSConfig{
-							InsecureSkipVerify: true,
-						},
-					},
+					TLSConfig:       scrapetls.TLSConfig(cell, ServingCertificate(cell)),
 					// MetricRelabelConfigs: append(configs, common.DropMetricsRelabeling(ctx)...),
 					MetricRelabelConfigs: configs,
-					RelabelConfigs: []*monitoringv1.RelabelConfig{
-						{
-							Action: "labeldrop",
-							Regex:  "(pod|service|endpoint|namespace)",
-						},
-					},
+					RelabelConfigs:       relabelConfigs(cell),
 				},
 				{
 					BearerTokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token",
 					Port:            "https-self",
 					Interval:        "60s",
 					Scheme:          "https",
-					TLSConfig: &monitoringv1.TLSConfig{
-						SafeTLSConfig: monitoringv1.SafeTLSConfig{
-							InsecureSkipVerify: true,
-						},
-					},
+					TLSConfig:       scrapetls.TLSConfig(cell, ServingCertificate(cell)),
 				},
 			},
 			JobLabel: "app.kubernetes.io/name",
@@ -120,3 +116,24 @@ func ServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor
 		},
 	}
 }
+
+func relabelConfigs(cell *monitoringv1beta1.Cell) []*monitoringv1.RelabelConfig {
+	configs := []*monitoringv1.RelabelConfig{
+		{
+			Action: "labeldrop",
+			Regex:  "(pod|service|endpoint|namespace)",
+		},
+	}
+	if Shards(cell) > 1 {
+		// Tell apart the shards by the ordinal of their pod
+		configs = append(configs, &monitoringv1.RelabelConfig{
+			Action:       "replace",
+			SourceLabels: []monitoringv1.LabelName{"__meta_kubernetes_pod_name"},
+			Regex:        ".*-([0-9]+)",
+			Replacement:  "$1",
+			TargetLabel:  "shard",
+		})
+	}
+
+	return configs
+}
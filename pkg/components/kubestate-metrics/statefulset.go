@@ -0,0 +1,73 @@
+package kubestatemetrics
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+// Shards returns the number of shards kube-state-metrics is split into.
+func Shards(cell *monitoringv1beta1.Cell) int32 {
+	if cell.Spec.KubeStateMetrics.Shards == nil || *cell.Spec.KubeStateMetrics.Shards < 1 {
+		return 1
+	}
+
+	return *cell.Spec.KubeStateMetrics.Shards
+}
+
+// StatefulSet runs kube-state-metrics sharded. Each replica finds its shard from its ordinal and the
+// replicas of the StatefulSet.
+func StatefulSet(cell *monitoringv1beta1.Cell) *appsv1.StatefulSet {
+	template := podTemplate(cell)
+	container := &template.Spec.Containers[0]
+	container.Args = append(container.Args,
+		"--pod=$(POD_NAME)",
+		"--pod-namespace=$(POD_NAMESPACE)",
+	)
+	container.Env = append(container.Env,
+		corev1.EnvVar{
+			Name: "POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+		corev1.EnvVar{
+			Name: "POD_NAMESPACE",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+			},
+		},
+	)
+
+	return &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", Name, cell.Name),
+			Namespace: cell.Namespace,
+			Labels:    Labels(cell),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: cell.APIVersion,
+					Kind:       cell.Kind,
+					Name:       cell.Name,
+					UID:        cell.UID,
+				},
+			},
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Selector:            &metav1.LabelSelector{MatchLabels: Labels(cell)},
+			Replicas:            pointer.Int32(Shards(cell)),
+			ServiceName:         fmt.Sprintf("%s-%s", Name, cell.Name),
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			Template:            template,
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package kubestatemetrics_test
+
+import (
+	"regexp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+)
+
+var _ = Describe("kube-state-metrics sharding", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "monitoring", Labels: map[string]string{}}}
+	})
+
+	It("runs a single shard unless the cell asks for more", func() {
+		Expect(kubestatemetrics.Shards(cell)).To(Equal(int32(1)))
+		cell.Spec.KubeStateMetrics.Shards = pointer.Int32(0)
+		Expect(kubestatemetrics.Shards(cell)).To(Equal(int32(1)))
+		cell.Spec.KubeStateMetrics.Shards = pointer.Int32(3)
+		Expect(kubestatemetrics.Shards(cell)).To(Equal(int32(3)))
+	})
+
+	Context("with several shards", func() {
+		BeforeEach(func() {
+			cell.Spec.KubeStateMetrics.Shards = pointer.Int32(3)
+		})
+
+		It("runs a replica per shard, each finding its shard from its own pod", func() {
+			sts := kubestatemetrics.StatefulSet(cell)
+			Expect(sts.Spec.Replicas).To(Equal(pointer.Int32(3)))
+			Expect(sts.Spec.PodManagementPolicy).To(Equal(appsv1.ParallelPodManagement))
+			Expect(sts.Spec.ServiceName).To(Equal(kubestatemetrics.Service(cell).Name))
+			Expect(sts.Spec.Selector.MatchLabels).To(Equal(sts.Spec.Template.Labels))
+
+			container := sts.Spec.Template.Spec.Containers[0]
+			Expect(container.Args).To(ContainElements("--pod=$(POD_NAME)", "--pod-namespace=$(POD_NAMESPACE)"))
+			Expect(container.Env).To(ContainElements(
+				HaveField("ValueFrom.FieldRef.FieldPath", "metadata.name"),
+				HaveField("ValueFrom.FieldRef.FieldPath", "metadata.namespace"),
+			))
+		})
+
+		It("runs the same pods as the Deployment besides the shard flags", func() {
+			sts := kubestatemetrics.StatefulSet(cell)
+			deployment := kubestatemetrics.Deployment(cell)
+			Expect(sts.Name).To(Equal(deployment.Name))
+			Expect(sts.Spec.Template.Spec.Containers[0].Args).To(HaveLen(len(deployment.Spec.Template.Spec.Containers[0].Args) + 2))
+			Expect(sts.Spec.Template.Spec.Containers[1:]).To(Equal(deployment.Spec.Template.Spec.Containers[1:]))
+		})
+
+		It("lets the shards look up their own pod and StatefulSet", func() {
+			Expect(kubestatemetrics.ClusterRole(cell).Rules).To(ContainElements(
+				rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+				rbacv1.PolicyRule{APIGroups: []string{"apps"}, Resources: []string{"statefulsets"}, Verbs: []string{"get"}},
+			))
+		})
+
+		It("labels the metrics of each shard with its ordinal", func() {
+			relabeling := kubestatemetrics.ServiceMonitor(cell).Spec.Endpoints[0].RelabelConfigs
+			Expect(relabeling).To(ContainElement(HaveField("TargetLabel", "shard")))
+			for _, config := range relabeling {
+				if config.TargetLabel != "shard" {
+					continue
+				}
+				Expect(config.SourceLabels).To(Equal([]monitoringv1.LabelName{"__meta_kubernetes_pod_name"}))
+				// Prometheus anchors relabeling regular expressions
+				regex := regexp.MustCompile("^(?:" + config.Regex + ")$")
+				Expect(regex.ReplaceAllString("kube-state-metrics-cell-12", config.Replacement)).To(Equal("12"))
+			}
+		})
+	})
+
+	It("doesn't label metrics with a shard nor grant lookups when it isn't sharded", func() {
+		Expect(kubestatemetrics.ServiceMonitor(cell).Spec.Endpoints[0].RelabelConfigs).NotTo(ContainElement(HaveField("TargetLabel", "shard")))
+		Expect(kubestatemetrics.ClusterRole(cell).Rules).NotTo(ContainElement(HaveField("Verbs", []string{"get"})))
+	})
+})
+
+var _ = Describe("kube-state-metrics relabeling", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Labels: map[string]string{}}}
+	})
+
+	targets := func() map[string]string {
+		renamed := map[string]string{}
+		for _, config := range kubestatemetrics.ServiceMonitor(cell).Spec.Endpoints[0].MetricRelabelConfigs {
+			if config.Action == "replace" {
+				renamed[string(config.SourceLabels[0])] = config.TargetLabel
+			}
+		}
+		return renamed
+	}
+
+	It("renames the node pool label of the provider to nodepool", func() {
+		cell.Spec.Provider = monitoringv1beta1.ProviderEKS
+		Expect(targets()).To(HaveKeyWithValue("label_eks_amazonaws_com_nodegroup", "nodepool"))
+	})
+
+	It("has no node pool label to rename on the generic provider", func() {
+		Expect(targets()).NotTo(ContainElement("nodepool"))
+		Expect(targets()).To(HaveKeyWithValue("label_topology_kubernetes_io_region", "region"))
+	})
+
+	It("drops every label it renames", func() {
+		var dropped []string
+		for _, config := range kubestatemetrics.ServiceMonitor(cell).Spec.Endpoints[0].MetricRelabelConfigs {
+			if config.Action == "labeldrop" {
+				dropped = append(dropped, config.Regex)
+			}
+		}
+		for source := range targets() {
+			Expect(dropped).To(ContainElement(source))
+		}
+	})
+})
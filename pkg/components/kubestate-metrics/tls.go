@@ -0,0 +1,14 @@
+package kubestatemetrics
+
+import (
+	"fmt"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/pki"
+)
+
+// ServingCertificate is the certificate the kube-rbac-proxy sidecars serve with when scrapes verify TLS. It's
+// valid for the name of the Service, as Prometheus scrapes the pods' addresses.
+func ServingCertificate(cell *monitoringv1beta1.Cell) pki.Certificate {
+	return pki.ServiceCertificate(fmt.Sprintf("%s-%s", Name, cell.Name), cell.Namespace)
+}
@@ -0,0 +1,189 @@
+// Package networkpolicy restricts the traffic in the cell's namespace when the cell's network policy mode is strict.
+// node-exporter runs on the host network, which NetworkPolicies don't apply to.
+package networkpolicy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	networkv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/gitpod"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/kubernetes"
+	kubestatemetrics "github.com/gitpod-io/monitoring-cell/pkg/components/kubestate-metrics"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/prometheus"
+	prometheusoperator "github.com/gitpod-io/monitoring-cell/pkg/components/prometheus-operator"
+)
+
+const Name = "network-policy"
+
+var (
+	// apiServerPorts covers the port of the kubernetes Service and the one the API server commonly listens on,
+	// as NetworkPolicies apply to the latter
+	apiServerPorts = []int32{443, 6443}
+	// scrapePorts are the ports of the cell's own targets: prometheus-operator and kube-state-metrics behind
+	// kube-rbac-proxy, kube-state-metrics' telemetry, Prometheus and its config reloader, node-exporter and the kubelet
+	scrapePorts = []int32{8443, 9443, 9090, 8080, 9100, 10250}
+)
+
+func Labels(cell *monitoringv1beta1.Cell) map[string]string {
+	c := cell.DeepCopy()
+	labels := c.Labels
+	labels["app.kubernetes.io/name"] = Name
+
+	return labels
+}
+
+// NetworkPolicies returns the policies of the cell's namespace in strict mode: one denying all traffic
+// and one per component allowing what it needs. The denying one comes last, so the components keep working
+// while the policies are first applied.
+func NetworkPolicies(cell *monitoringv1beta1.Cell) []*networkv1.NetworkPolicy {
+	if cell.Spec.NetworkPolicy.Mode != monitoringv1beta1.NetworkPolicyStrict {
+		return nil
+	}
+
+	fromPrometheus := []networkv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: prometheus.Labels(cell)}}}
+	infrastructure := append(dns(), networkv1.NetworkPolicyEgressRule{Ports: tcp(apiServerPorts...)})
+
+	// Prometheus scrapes itself, which mustn't depend on who may use the UI
+	fromUI := cell.Spec.NetworkPolicy.PrometheusUIFrom
+	if len(fromUI) > 0 {
+		fromUI = append(append([]networkv1.NetworkPolicyPeer{}, fromUI...), fromPrometheus...)
+	}
+	prometheusEgress := append(infrastructure, networkv1.NetworkPolicyEgressRule{Ports: tcp(prometheusScrapePorts(cell)...)})
+	if ports := remoteWritePorts(cell); len(ports) > 0 {
+		prometheusEgress = append(prometheusEgress, networkv1.NetworkPolicyEgressRule{Ports: tcp(ports...)})
+	}
+
+	return []*networkv1.NetworkPolicy{
+		policy(cell, fmt.Sprintf("%s-%s", prometheus.Name, cell.Name), prometheus.Labels(cell),
+			[]networkv1.NetworkPolicyIngressRule{
+				{From: fromUI, Ports: tcp(9090)},
+				{From: fromPrometheus, Ports: tcp(8080)},
+			},
+			prometheusEgress,
+		),
+		policy(cell, fmt.Sprintf("%s-%s", prometheusoperator.Name, cell.Name), prometheusoperator.Labels(cell),
+			[]networkv1.NetworkPolicyIngressRule{{From: fromPrometheus, Ports: tcp(8443)}},
+			infrastructure,
+		),
+		policy(cell, fmt.Sprintf("%s-%s", kubestatemetrics.Name, cell.Name), kubestatemetrics.Labels(cell),
+			[]networkv1.NetworkPolicyIngressRule{{From: fromPrometheus, Ports: tcp(8443, 9443)}},
+			infrastructure,
+		),
+		policy(cell, fmt.Sprintf("default-deny-%s", cell.Name), nil, nil, nil),
+	}
+}
+
+func policy(cell *monitoringv1beta1.Cell, name string, podLabels map[string]string, ingress []networkv1.NetworkPolicyIngressRule, egress []networkv1.NetworkPolicyEgressRule) *networkv1.NetworkPolicy {
+	return &networkv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "NetworkPolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cell.Namespace,
+			Labels:    Labels(cell),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: cell.APIVersion,
+					Kind:       cell.Kind,
+					Name:       cell.Name,
+					UID:        cell.UID,
+				},
+			},
+		},
+		Spec: networkv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podLabels},
+			Ingress:     ingress,
+			Egress:      egress,
+			PolicyTypes: []networkv1.PolicyType{
+				networkv1.PolicyTypeIngress,
+				networkv1.PolicyTypeEgress,
+			},
+		},
+	}
+}
+
+// dns allows resolving names with the cluster's DNS in kube-system
+func dns() []networkv1.NetworkPolicyEgressRule {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	port := intstr.FromInt(53)
+
+	return []networkv1.NetworkPolicyEgressRule{{
+		To: []networkv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"kubernetes.io/metadata.name": kubernetes.Namespace},
+			},
+		}},
+		Ports: []networkv1.NetworkPolicyPort{{Protocol: &udp, Port: &port}, {Protocol: &tcp, Port: &port}},
+	}}
+}
+
+// prometheusScrapePorts are the ports of all of Prometheus' targets. They're allowed to any destination, as
+// the kubelet and node-exporter listen on the nodes' addresses.
+func prometheusScrapePorts(cell *monitoringv1beta1.Cell) []int32 {
+	ports := append([]int32{}, scrapePorts...)
+	ports = append(ports, gitpod.MetricsPorts...)
+	for _, component := range kubernetes.EnabledComponents(cell) {
+		ports = append(ports, component.Port)
+	}
+
+	return unique(ports)
+}
+
+// remoteWritePorts are the ports of the remote-write endpoints. Their addresses aren't known up front, so
+// they're allowed to any destination.
+func remoteWritePorts(cell *monitoringv1beta1.Cell) []int32 {
+	var ports []int32
+	for _, rw := range cell.Spec.Metrics.RemoteWrite {
+		u, err := url.Parse(rw.URL)
+		if err != nil {
+			continue
+		}
+		switch {
+		case u.Port() != "":
+			if port, err := strconv.ParseInt(u.Port(), 10, 32); err == nil {
+				ports = append(ports, int32(port))
+			}
+		case u.Scheme == "http":
+			ports = append(ports, 80)
+		default:
+			ports = append(ports, 443)
+		}
+	}
+
+	return unique(ports)
+}
+
+func tcp(ports ...int32) []networkv1.NetworkPolicyPort {
+	protocol := corev1.ProtocolTCP
+	var policyPorts []networkv1.NetworkPolicyPort
+	for _, p := range ports {
+		port := intstr.FromInt(int(p))
+		policyPorts = append(policyPorts, networkv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+	}
+
+	return policyPorts
+}
+
+func unique(ports []int32) []int32 {
+	seen := map[int32]bool{}
+	var result []int32
+	for _, p := range ports {
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
@@ -0,0 +1,113 @@
+package networkpolicy_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	pov1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/gitpod"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/kubernetes"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/networkpolicy"
+)
+
+var _ = Describe("NetworkPolicies", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{ObjectMeta: metav1.ObjectMeta{Name: "cell", Namespace: "monitoring", Labels: map[string]string{}}}
+		cell.Spec.NetworkPolicy.Mode = monitoringv1beta1.NetworkPolicyStrict
+	})
+
+	ports := func(rule networkv1.NetworkPolicyEgressRule) []int32 {
+		var ports []int32
+		for _, p := range rule.Ports {
+			Expect(*p.Protocol).To(Equal(corev1.ProtocolTCP))
+			ports = append(ports, p.Port.IntVal)
+		}
+		return ports
+	}
+	// prometheusEgress returns the egress rules of Prometheus beyond DNS and the API server: its scrape
+	// ports, followed by the ports of its remote-write endpoints if it has any
+	prometheusEgress := func() []networkv1.NetworkPolicyEgressRule {
+		policies := networkpolicy.NetworkPolicies(cell)
+		Expect(policies[0].Name).To(Equal("prometheus-cell"))
+		return policies[0].Spec.Egress[2:]
+	}
+	remoteWrite := func(urls ...string) {
+		for _, u := range urls {
+			cell.Spec.Metrics.RemoteWrite = append(cell.Spec.Metrics.RemoteWrite, monitoringv1beta1.RemoteWriteSpec{RemoteWriteSpec: pov1.RemoteWriteSpec{URL: u}})
+		}
+	}
+
+	It("renders nothing unless the mode is strict", func() {
+		cell.Spec.NetworkPolicy.Mode = monitoringv1beta1.NetworkPolicyPermissive
+		Expect(networkpolicy.NetworkPolicies(cell)).To(BeEmpty())
+	})
+
+	It("denies all other traffic last", func() {
+		policies := networkpolicy.NetworkPolicies(cell)
+		deny := policies[len(policies)-1]
+		Expect(deny.Spec.PodSelector.MatchLabels).To(BeEmpty())
+		Expect(deny.Spec.Ingress).To(BeEmpty())
+		Expect(deny.Spec.Egress).To(BeEmpty())
+		Expect(deny.Spec.PolicyTypes).To(ConsistOf(networkv1.PolicyTypeIngress, networkv1.PolicyTypeEgress))
+	})
+
+	It("lets Prometheus scrape each port once, sorted, including those of Gitpod and enabled Kubernetes components", func() {
+		cell.Spec.Kubernetes.Etcd.Enabled = pointer.Bool(true)
+		cell.Spec.Kubernetes.CoreDNS.Enabled = pointer.Bool(true)
+		egress := prometheusEgress()
+		Expect(egress).To(HaveLen(1))
+
+		scrape := ports(egress[0])
+		Expect(scrape).To(ContainElements(int32(8443), int32(9443), int32(9090), int32(8080), int32(9100), int32(10250)))
+		Expect(scrape).To(ContainElements(gitpod.MetricsPorts))
+		Expect(scrape).To(ContainElements(kubernetes.Etcd.Port, kubernetes.CoreDNS.Port))
+		Expect(scrape).NotTo(ContainElement(kubernetes.Scheduler.Port))
+		for i := 1; i < len(scrape); i++ {
+			Expect(scrape[i]).To(BeNumerically(">", scrape[i-1]))
+		}
+	})
+
+	It("adds no remote-write rule without remote-write endpoints, which would allow all ports", func() {
+		Expect(prometheusEgress()).To(HaveLen(1))
+	})
+
+	It("derives the remote-write ports from the endpoints' URLs", func() {
+		remoteWrite(
+			"https://metrics.example.com/api/v1/write",
+			"http://mimir.monitoring.svc/api/v1/push",
+			"https://metrics.example.com:8443/write",
+			"https://other.example.com/write",
+		)
+		egress := prometheusEgress()
+		Expect(egress).To(HaveLen(2))
+		Expect(ports(egress[1])).To(Equal([]int32{80, 443, 8443}))
+		Expect(egress[1].To).To(BeEmpty())
+	})
+
+	It("skips remote-write URLs it can't parse", func() {
+		remoteWrite("https://metrics.example.com:notaport/write", "://missing-scheme")
+		Expect(prometheusEgress()).To(HaveLen(1))
+
+		remoteWrite("https://metrics.example.com:9009/write")
+		egress := prometheusEgress()
+		Expect(egress).To(HaveLen(2))
+		Expect(ports(egress[1])).To(Equal([]int32{9009}))
+	})
+
+	It("lets the UI be reached from the configured peers and Prometheus itself", func() {
+		ui := networkv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "sre"}}}
+		cell.Spec.NetworkPolicy.PrometheusUIFrom = []networkv1.NetworkPolicyPeer{ui}
+		ingress := networkpolicy.NetworkPolicies(cell)[0].Spec.Ingress
+		Expect(ingress[0].From).To(HaveLen(2))
+		Expect(ingress[0].From[0]).To(Equal(ui))
+		Expect(cell.Spec.NetworkPolicy.PrometheusUIFrom).To(HaveLen(1), "the cell's peers must not be modified")
+	})
+})
@@ -1,14 +1,21 @@
 package nodeexporter
 
-import monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+import monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 
 const (
 	Name     = "node-exporter"
 	Version  = "1.3.1"
 	ImageURL = "quay.io/prometheus/node-exporter"
+
+	DefaultNetDeviceExclude = "^(veth.*|[a-f0-9]{15})$"
+
+	// NodePoolLabel on node-exporter pods names the profile they run with
+	NodePoolLabel = "monitoring.gitpod.io/nodepool"
+
+	textfileDirectory = "/host/textfile"
 )
 
-func Labels(cell *monitoringv1alpha1.Cell) map[string]string {
+func Labels(cell *monitoringv1beta1.Cell) map[string]string {
 	c := cell.DeepCopy()
 	labels := c.Labels
 	labels["app.kubernetes.io/name"] = Name
@@ -10,22 +10,62 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/scrapetls"
+	"github.com/gitpod-io/monitoring-cell/pkg/provider"
 )
 
-func Daemonset(cell *monitoringv1alpha1.Cell) *appsv1.DaemonSet {
+// Daemonsets returns a DaemonSet per profile of the cell, or a single one running on all nodes if it has none.
+func Daemonsets(cell *monitoringv1beta1.Cell) []*appsv1.DaemonSet {
+	spec := cell.Spec.NodeExporter
+	if len(spec.Profiles) == 0 {
+		return []*appsv1.DaemonSet{
+			daemonset(cell, fmt.Sprintf("%s-%s", Name, cell.Name), Labels(cell), monitoringv1beta1.NodeExporterProfile{NodeExporterConfig: spec.NodeExporterConfig}),
+		}
+	}
+
+	var daemonsets []*appsv1.DaemonSet
+	for _, profile := range spec.Profiles {
+		labels := Labels(cell)
+		labels[NodePoolLabel] = profile.Name
+		profile.NodeExporterConfig = withDefaults(profile.NodeExporterConfig, spec.NodeExporterConfig)
+		daemonsets = append(daemonsets, daemonset(cell, fmt.Sprintf("%s-%s-%s", Name, cell.Name, profile.Name), labels, profile))
+	}
+
+	return daemonsets
+}
+
+func daemonset(cell *monitoringv1beta1.Cell, name string, labels map[string]string, profile monitoringv1beta1.NodeExporterProfile) *appsv1.DaemonSet {
 	hostToContainer := v1.MountPropagationHostToContainer
 	maxUnavailable := intstr.FromString("10%")
 
-	return &appsv1.DaemonSet{
+	tolerations := profile.Tolerations
+	if tolerations == nil {
+		tolerations = []v1.Toleration{
+			{
+				Operator: v1.TolerationOpExists,
+			},
+		}
+	}
+	resources := v1.ResourceRequirements{
+		Requests: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("100m"),
+			v1.ResourceMemory: resource.MustParse("180Mi"),
+		},
+	}
+	if profile.Resources != nil {
+		resources = *profile.Resources
+	}
+
+	ds := &appsv1.DaemonSet{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
 			Kind:       "DaemonSet",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s", Name, cell.Name),
+			Name:      name,
 			Namespace: cell.Namespace,
-			Labels:    Labels(cell),
+			Labels:    labels,
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: cell.APIVersion,
@@ -37,7 +77,7 @@ func Daemonset(cell *monitoringv1alpha1.Cell) *appsv1.DaemonSet {
 		},
 		Spec: appsv1.DaemonSetSpec{
 			Selector: &metav1.LabelSelector{
-				MatchLabels: Labels(cell),
+				MatchLabels: labels,
 			},
 			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
 				Type: appsv1.RollingUpdateDaemonSetStrategyType,
@@ -47,7 +87,7 @@ func Daemonset(cell *monitoringv1alpha1.Cell) *appsv1.DaemonSet {
 			},
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: Labels(cell),
+					Labels: labels,
 					Annotations: map[string]string{
 						"kubectl.kubernetes.io/default-container": "node-exporter",
 					},
@@ -60,13 +100,10 @@ func Daemonset(cell *monitoringv1alpha1.Cell) *appsv1.DaemonSet {
 						RunAsNonRoot: pointer.Bool(true),
 						RunAsUser:    pointer.Int64(65534),
 					},
+					NodeSelector:       profile.NodeSelector,
 					ServiceAccountName: fmt.Sprintf("%s-%s", Name, cell.Name),
-					Tolerations: []v1.Toleration{
-						{
-							Operator: v1.TolerationOpExists,
-						},
-					},
-					Volumes: []v1.Volume{
+					Tolerations:        tolerations,
+					Volumes: append([]v1.Volume{
 						{
 							Name: "sys",
 							VolumeSource: v1.VolumeSource{
@@ -83,27 +120,13 @@ func Daemonset(cell *monitoringv1alpha1.Cell) *appsv1.DaemonSet {
 								},
 							},
 						},
-					},
+					}, textfileVolumes(profile.NodeExporterConfig)...),
 					Containers: []v1.Container{
 						{
-							Args: []string{
-								"--web.listen-address=127.0.0.1:9100",
-								"--path.sysfs=/host/sys",
-								"--path.rootfs=/host/root",
-								"--no-collector.wifi",
-								"--no-collector.hwmon",
-								"--collector.filesystem.mount-points-exclude=^/(dev|proc|sys|run/k3s/containerd/.+|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
-								"--collector.netclass.ignored-devices=^(veth.*|[a-f0-9]{15})$",
-								"--collector.netdev.device-exclude=^(veth.*|[a-f0-9]{15})$",
-							},
-							Image: fmt.Sprintf("%s:v%s", ImageURL, Version),
-							Name:  Name,
-							Resources: v1.ResourceRequirements{
-								Requests: v1.ResourceList{
-									v1.ResourceCPU:    resource.MustParse("100m"),
-									v1.ResourceMemory: resource.MustParse("180Mi"),
-								},
-							},
+							Args:      args(profile.NodeExporterConfig, provider.For(cell)),
+							Image:     fmt.Sprintf("%s:v%s", ImageURL, Version),
+							Name:      Name,
+							Resources: resources,
 							SecurityContext: &v1.SecurityContext{
 								AllowPrivilegeEscalation: pointer.Bool(false),
 								Capabilities: &v1.Capabilities{
@@ -112,7 +135,7 @@ func Daemonset(cell *monitoringv1alpha1.Cell) *appsv1.DaemonSet {
 								},
 								ReadOnlyRootFilesystem: pointer.Bool(true),
 							},
-							VolumeMounts: []v1.VolumeMount{
+							VolumeMounts: append([]v1.VolumeMount{
 								{
 									MountPath:        "/host/sys",
 									Name:             "sys",
@@ -125,7 +148,7 @@ func Daemonset(cell *monitoringv1alpha1.Cell) *appsv1.DaemonSet {
 									ReadOnly:         true,
 									MountPropagation: &hostToContainer,
 								},
-							},
+							}, textfileVolumeMounts(profile.NodeExporterConfig)...),
 						},
 						{
 							Args: []string{
@@ -178,4 +201,110 @@ func Daemonset(cell *monitoringv1alpha1.Cell) *appsv1.DaemonSet {
 			},
 		},
 	}
+	scrapetls.WithServingCertificate(cell, ServingCertificate(cell), &ds.Spec.Template.Spec, func(c v1.Container) bool {
+		return c.Name == "kube-rbac-proxy"
+	})
+
+	return ds
+}
+
+// withDefaults fills the collector settings a profile leaves empty from those of the cell.
+func withDefaults(config, defaults monitoringv1beta1.NodeExporterConfig) monitoringv1beta1.NodeExporterConfig {
+	if config.EnabledCollectors == nil {
+		config.EnabledCollectors = defaults.EnabledCollectors
+	}
+	if config.DisabledCollectors == nil {
+		config.DisabledCollectors = defaults.DisabledCollectors
+	}
+	if config.MountPointsExclude == "" {
+		config.MountPointsExclude = defaults.MountPointsExclude
+	}
+	if config.NetDeviceExclude == "" {
+		config.NetDeviceExclude = defaults.NetDeviceExclude
+	}
+	if config.Textfile == nil {
+		config.Textfile = defaults.Textfile
+	}
+
+	return config
+}
+
+// defaultDisabledCollectors are turned off unless they're enabled explicitly
+var defaultDisabledCollectors = []monitoringv1beta1.CollectorName{"wifi", "hwmon"}
+
+func args(spec monitoringv1beta1.NodeExporterConfig, profile provider.Profile) []string {
+	args := []string{
+		"--web.listen-address=127.0.0.1:9100",
+		"--path.sysfs=/host/sys",
+		"--path.rootfs=/host/root",
+	}
+
+	enabled := map[monitoringv1beta1.CollectorName]bool{}
+	for _, c := range spec.EnabledCollectors {
+		enabled[c] = true
+	}
+	for _, c := range defaultDisabledCollectors {
+		if !enabled[c] {
+			args = append(args, "--no-collector."+string(c))
+		}
+	}
+	for _, c := range spec.DisabledCollectors {
+		if !enabled[c] {
+			args = append(args, "--no-collector."+string(c))
+		}
+	}
+	for _, c := range spec.EnabledCollectors {
+		args = append(args, "--collector."+string(c))
+	}
+
+	mountPointsExclude := profile.MountPointsExclude
+	if spec.MountPointsExclude != "" {
+		mountPointsExclude = spec.MountPointsExclude
+	}
+	netDeviceExclude := DefaultNetDeviceExclude
+	if spec.NetDeviceExclude != "" {
+		netDeviceExclude = spec.NetDeviceExclude
+	}
+	args = append(args,
+		"--collector.filesystem.mount-points-exclude="+mountPointsExclude,
+		"--collector.netclass.ignored-devices="+netDeviceExclude,
+		"--collector.netdev.device-exclude="+netDeviceExclude,
+	)
+
+	if spec.Textfile != nil {
+		args = append(args, "--collector.textfile.directory="+textfileDirectory)
+	}
+
+	return args
+}
+
+func textfileVolumes(spec monitoringv1beta1.NodeExporterConfig) []v1.Volume {
+	if spec.Textfile == nil {
+		return nil
+	}
+
+	source := v1.VolumeSource{
+		ConfigMap: &v1.ConfigMapVolumeSource{
+			LocalObjectReference: v1.LocalObjectReference{Name: spec.Textfile.ConfigMap},
+		},
+	}
+	if spec.Textfile.HostPath != "" {
+		directory := v1.HostPathDirectory
+		source = v1.VolumeSource{
+			HostPath: &v1.HostPathVolumeSource{
+				Path: spec.Textfile.HostPath,
+				Type: &directory,
+			},
+		}
+	}
+
+	return []v1.Volume{{Name: "textfile", VolumeSource: source}}
+}
+
+func textfileVolumeMounts(spec monitoringv1beta1.NodeExporterConfig) []v1.VolumeMount {
+	if spec.Textfile == nil {
+		return nil
+	}
+
+	return []v1.VolumeMount{{MountPath: textfileDirectory, Name: "textfile", ReadOnly: true}}
 }
@@ -0,0 +1,191 @@
+package nodeexporter_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	nodeexporter "github.com/gitpod-io/monitoring-cell/pkg/components/node-exporter"
+	"github.com/gitpod-io/monitoring-cell/pkg/provider"
+)
+
+var _ = Describe("DaemonSets", func() {
+	var cell *monitoringv1beta1.Cell
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{}
+		cell.Name = "cell"
+		cell.Namespace = "monitoring"
+		cell.Labels = map[string]string{}
+	})
+
+	nodeExporter := func(ds *appsv1.DaemonSet) corev1.Container {
+		return ds.Spec.Template.Spec.Containers[0]
+	}
+	// collectorArgs are the args of the single DaemonSet that turn collectors on or off
+	collectorArgs := func() []string {
+		var collectors []string
+		for _, arg := range nodeExporter(nodeexporter.Daemonsets(cell)[0]).Args {
+			if (strings.HasPrefix(arg, "--collector.") || strings.HasPrefix(arg, "--no-collector.")) && !strings.Contains(arg, "=") {
+				collectors = append(collectors, arg)
+			}
+		}
+		return collectors
+	}
+
+	Describe("collectors", func() {
+		DescribeTable("turned on and off",
+			func(enabled, disabled []monitoringv1beta1.CollectorName, expected []string) {
+				cell.Spec.NodeExporter.EnabledCollectors = enabled
+				cell.Spec.NodeExporter.DisabledCollectors = disabled
+				Expect(collectorArgs()).To(Equal(expected))
+			},
+			Entry("turn off wifi and hwmon by default",
+				nil, nil,
+				[]string{"--no-collector.wifi", "--no-collector.hwmon"}),
+			Entry("turn on the enabled ones, including those off by default",
+				[]monitoringv1beta1.CollectorName{"hwmon", "systemd"}, nil,
+				[]string{"--no-collector.wifi", "--collector.hwmon", "--collector.systemd"}),
+			Entry("turn off the disabled ones along with the defaults",
+				nil, []monitoringv1beta1.CollectorName{"ntp", "mdadm"},
+				[]string{"--no-collector.wifi", "--no-collector.hwmon", "--no-collector.ntp", "--no-collector.mdadm"}),
+			Entry("turn on the ones both enabled and disabled",
+				[]monitoringv1beta1.CollectorName{"ntp"}, []monitoringv1beta1.CollectorName{"ntp", "mdadm"},
+				[]string{"--no-collector.wifi", "--no-collector.hwmon", "--no-collector.mdadm", "--collector.ntp"}),
+		)
+
+		It("exclude the provider's mount points and the default network devices", func() {
+			cell.Spec.Provider = monitoringv1beta1.ProviderGKE
+			Expect(nodeExporter(nodeexporter.Daemonsets(cell)[0]).Args).To(ContainElements(
+				"--collector.filesystem.mount-points-exclude="+provider.For(cell).MountPointsExclude,
+				"--collector.netclass.ignored-devices="+nodeexporter.DefaultNetDeviceExclude,
+				"--collector.netdev.device-exclude="+nodeexporter.DefaultNetDeviceExclude,
+			))
+		})
+
+		It("exclude the mount points and network devices of the cell over the defaults", func() {
+			cell.Spec.NodeExporter.MountPointsExclude = "^/dev($|/)"
+			cell.Spec.NodeExporter.NetDeviceExclude = "^veth.*$"
+			Expect(nodeExporter(nodeexporter.Daemonsets(cell)[0]).Args).To(ContainElements(
+				"--collector.filesystem.mount-points-exclude=^/dev($|/)",
+				"--collector.netclass.ignored-devices=^veth.*$",
+				"--collector.netdev.device-exclude=^veth.*$",
+			))
+		})
+	})
+
+	Describe("profiles", func() {
+		textfile := &monitoringv1beta1.TextfileCollectorSpec{HostPath: "/var/lib/node-exporter"}
+
+		BeforeEach(func() {
+			cell.Spec.NodeExporter.NodeExporterConfig = monitoringv1beta1.NodeExporterConfig{
+				EnabledCollectors:  []monitoringv1beta1.CollectorName{"systemd"},
+				DisabledCollectors: []monitoringv1beta1.CollectorName{"ntp"},
+				MountPointsExclude: "^/dev($|/)",
+				NetDeviceExclude:   "^veth.*$",
+				Textfile:           textfile,
+			}
+		})
+
+		It("take the settings they leave empty from the cell", func() {
+			cell.Spec.NodeExporter.Profiles = []monitoringv1beta1.NodeExporterProfile{{Name: "workspaces"}}
+
+			daemonsets := nodeexporter.Daemonsets(cell)
+			Expect(daemonsets).To(HaveLen(1))
+			Expect(nodeExporter(daemonsets[0]).Args).To(ContainElements(
+				"--collector.systemd",
+				"--no-collector.ntp",
+				"--collector.filesystem.mount-points-exclude=^/dev($|/)",
+				"--collector.netdev.device-exclude=^veth.*$",
+				"--collector.textfile.directory=/host/textfile",
+			))
+		})
+
+		It("keep the settings they set over the cell's", func() {
+			cell.Spec.NodeExporter.Profiles = []monitoringv1beta1.NodeExporterProfile{{
+				Name: "workspaces",
+				NodeExporterConfig: monitoringv1beta1.NodeExporterConfig{
+					EnabledCollectors:  []monitoringv1beta1.CollectorName{"processes"},
+					DisabledCollectors: []monitoringv1beta1.CollectorName{},
+					MountPointsExclude: "^/proc($|/)",
+					Textfile:           &monitoringv1beta1.TextfileCollectorSpec{ConfigMap: "workspace-metrics"},
+				},
+			}}
+
+			ne := nodeExporter(nodeexporter.Daemonsets(cell)[0])
+			Expect(ne.Args).To(ContainElements(
+				"--collector.processes",
+				"--collector.filesystem.mount-points-exclude=^/proc($|/)",
+				"--collector.netdev.device-exclude=^veth.*$",
+			))
+			// An empty list is set, and turns off none of the cell's collectors
+			Expect(ne.Args).NotTo(ContainElement("--collector.systemd"))
+			Expect(ne.Args).NotTo(ContainElement("--no-collector.ntp"))
+			Expect(nodeexporter.Daemonsets(cell)[0].Spec.Template.Spec.Volumes).To(ContainElement(HaveField("ConfigMap.Name", "workspace-metrics")))
+		})
+
+		It("don't change the settings of the cell or of other profiles", func() {
+			cell.Spec.NodeExporter.Profiles = []monitoringv1beta1.NodeExporterProfile{
+				{Name: "workspaces", NodeExporterConfig: monitoringv1beta1.NodeExporterConfig{MountPointsExclude: "^/proc($|/)"}},
+				{Name: "services"},
+			}
+
+			daemonsets := nodeexporter.Daemonsets(cell)
+			Expect(nodeExporter(daemonsets[1]).Args).To(ContainElement("--collector.filesystem.mount-points-exclude=^/dev($|/)"))
+			Expect(cell.Spec.NodeExporter.Profiles[1].NodeExporterConfig).To(BeZero())
+		})
+	})
+
+	Describe("textfile collector", func() {
+		mount := corev1.VolumeMount{Name: "textfile", MountPath: "/host/textfile", ReadOnly: true}
+
+		textfileVolumes := func(ds *appsv1.DaemonSet) []corev1.Volume {
+			var volumes []corev1.Volume
+			for _, volume := range ds.Spec.Template.Spec.Volumes {
+				if volume.Name == "textfile" {
+					volumes = append(volumes, volume)
+				}
+			}
+			return volumes
+		}
+
+		It("reads a directory on the nodes", func() {
+			cell.Spec.NodeExporter.Textfile = &monitoringv1beta1.TextfileCollectorSpec{HostPath: "/var/lib/node-exporter"}
+
+			ds := nodeexporter.Daemonsets(cell)[0]
+			directory := corev1.HostPathDirectory
+			Expect(textfileVolumes(ds)).To(Equal([]corev1.Volume{{
+				Name:         "textfile",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/lib/node-exporter", Type: &directory}},
+			}}))
+			Expect(nodeExporter(ds).VolumeMounts).To(ContainElement(mount))
+			Expect(nodeExporter(ds).Args).To(ContainElement("--collector.textfile.directory=/host/textfile"))
+		})
+
+		It("reads the keys of a ConfigMap", func() {
+			cell.Spec.NodeExporter.Textfile = &monitoringv1beta1.TextfileCollectorSpec{ConfigMap: "node-metrics"}
+
+			ds := nodeexporter.Daemonsets(cell)[0]
+			Expect(textfileVolumes(ds)).To(Equal([]corev1.Volume{{
+				Name: "textfile",
+				VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "node-metrics"},
+				}},
+			}}))
+			Expect(nodeExporter(ds).VolumeMounts).To(ContainElement(mount))
+			Expect(nodeExporter(ds).Args).To(ContainElement("--collector.textfile.directory=/host/textfile"))
+		})
+
+		It("isn't set up without a source", func() {
+			ds := nodeexporter.Daemonsets(cell)[0]
+			Expect(textfileVolumes(ds)).To(BeEmpty())
+			Expect(nodeExporter(ds).VolumeMounts).NotTo(ContainElement(mount))
+			Expect(nodeExporter(ds).Args).NotTo(ContainElement(HavePrefix("--collector.textfile.directory")))
+		})
+	})
+})
@@ -0,0 +1,14 @@
+package nodeexporter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNodeExporter(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "node-exporter Suite")
+}
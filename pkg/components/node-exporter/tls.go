@@ -0,0 +1,14 @@
+package nodeexporter
+
+import (
+	"fmt"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/pki"
+)
+
+// ServingCertificate is the certificate kube-rbac-proxy serves with when scrapes verify TLS. It's valid for
+// the name of the Service, as Prometheus scrapes the node's address.
+func ServingCertificate(cell *monitoringv1beta1.Cell) pki.Certificate {
+	return pki.ServiceCertificate(fmt.Sprintf("%s-%s", Name, cell.Name), cell.Namespace)
+}
@@ -1,6 +1,6 @@
 package prometheusoperator
 
-import monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+import monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 
 const (
 	Name     = "prometheus-operator"
@@ -8,7 +8,7 @@ const (
 	ImageURL = "quay.io/prometheus-operator/prometheus-operator"
 )
 
-func Labels(cell *monitoringv1alpha1.Cell) map[string]string {
+func Labels(cell *monitoringv1beta1.Cell) map[string]string {
 	c := cell.DeepCopy()
 	labels := c.Labels
 	labels["app.kubernetes.io/name"] = Name
@@ -9,11 +9,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/scrapetls"
 )
 
-func Deployment(cell *monitoringv1alpha1.Cell) *appsv1.Deployment {
-	return &appsv1.Deployment{
+func Deployment(cell *monitoringv1beta1.Cell) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "apps/v1",
 			Kind:       "Deployment",
@@ -115,4 +116,9 @@ func Deployment(cell *monitoringv1alpha1.Cell) *appsv1.Deployment {
 			},
 		},
 	}
+	scrapetls.WithServingCertificate(cell, ServingCertificate(cell), &deployment.Spec.Template.Spec, func(c corev1.Container) bool {
+		return c.Name == "kube-rbac-proxy"
+	})
+
+	return deployment
 }
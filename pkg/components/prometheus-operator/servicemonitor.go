@@ -6,10 +6,11 @@ import (
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/scrapetls"
 )
 
-func ServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor {
+func ServiceMonitor(cell *monitoringv1beta1.Cell) *monitoringv1.ServiceMonitor {
 	return &monitoringv1.ServiceMonitor{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.coreos.com/v1",
@@ -39,12 +40,8 @@ func ServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor
 					Interval:        "60s",
 					Port:            "https",
 					Scheme:          "https",
-					TLSConfig: &monitoringv1.TLSConfig{
-						SafeTLSConfig: monitoringv1.SafeTLSConfig{
-							InsecureSkipVerify: true,
-						},
-					},
-					// MetricRelabelConfigs: should drop from cell.Spec.Metrics.Droplist,
+					TLSConfig:       scrapetls.TLSConfig(cell, ServingCertificate(cell)),
+					// MetricRelabelConfigs: should drop from cell.Spec.Metrics.DropList,
 				},
 			},
 		},
@@ -0,0 +1,13 @@
+package prometheusoperator
+
+import (
+	"fmt"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/pki"
+)
+
+// ServingCertificate is the certificate kube-rbac-proxy serves with when scrapes verify TLS.
+func ServingCertificate(cell *monitoringv1beta1.Cell) pki.Certificate {
+	return pki.ServiceCertificate(fmt.Sprintf("%s-%s", Name, cell.Name), cell.Namespace)
+}
@@ -5,11 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/Jeffail/gabs"
 	"k8s.io/client-go/rest"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 
 	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
 )
@@ -22,7 +23,7 @@ type Response struct {
 }
 
 // apiRequest makes a request against specified Prometheus API endpoint
-func apiRequest(endpoint string, selector string, query string, cell *monitoringv1alpha1.Cell, restClient rest.Interface) (Response, error) {
+func apiRequest(endpoint string, selector string, query string, cell *monitoringv1beta1.Cell, restClient rest.Interface) (Response, error) {
 	req := restClient.Get().
 		Namespace(cell.Namespace).
 		Resource("pods").
@@ -51,7 +52,7 @@ func apiRequest(endpoint string, selector string, query string, cell *monitoring
 }
 
 // Query makes a request against the Prometheus /api/v1/query endpoint.
-func Query(query string, cell *monitoringv1alpha1.Cell, restClient rest.Interface) (int, error) {
+func Query(query string, cell *monitoringv1beta1.Cell, restClient rest.Interface) (int, error) {
 	req := restClient.Get().
 		Namespace(cell.Namespace).
 		Resource("pods").
@@ -75,7 +76,7 @@ func Query(query string, cell *monitoringv1alpha1.Cell, restClient rest.Interfac
 
 // metadata makes a request against the Prometheus /api/v1/targets/metadata endpoint.
 // It returns all the metrics and its metadata.
-func Metadata(query string, cell *monitoringv1alpha1.Cell, restClient rest.Interface) ([]promv1.MetricMetadata, error) {
+func Metadata(query string, cell *monitoringv1beta1.Cell, restClient rest.Interface) ([]promv1.MetricMetadata, error) {
 	var metadata []promv1.MetricMetadata
 	rsp, err := apiRequest("/api/v1/targets/metadata", "match_target", query, cell, restClient)
 	if err != nil {
@@ -91,9 +92,56 @@ func Metadata(query string, cell *monitoringv1alpha1.Cell, restClient rest.Inter
 	return metadata, err
 }
 
+// Sample is a single series of an instant vector returned by a query.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// QueryVector makes a request against the Prometheus /api/v1/query endpoint.
+// It returns the series of the resulting instant vector.
+func QueryVector(query string, cell *monitoringv1beta1.Cell, restClient rest.Interface) ([]Sample, error) {
+	rsp, err := apiRequest("/api/v1/query", "query", query, cell, restClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(rsp.Data, &data); err != nil {
+		return nil, err
+	}
+	if data.ResultType != "vector" {
+		return nil, fmt.Errorf("expected an instant vector, got %s", data.ResultType)
+	}
+
+	samples := make([]Sample, 0, len(data.Result))
+	for _, r := range data.Result {
+		if len(r.Value) != 2 {
+			return nil, fmt.Errorf("unexpected sample value %v", r.Value)
+		}
+		s, ok := r.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected sample value %v", r.Value)
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, Sample{Labels: r.Metric, Value: v})
+	}
+
+	return samples, nil
+}
+
 // targets makes a request against the Prometheus /api/v1/targets endpoint.
 // It returns all targets registered in prometheus.
-func Targets(cell *monitoringv1alpha1.Cell, restClient rest.Interface) (promv1.TargetsResult, error) {
+func Targets(cell *monitoringv1beta1.Cell, restClient rest.Interface) (promv1.TargetsResult, error) {
 	var targets promv1.TargetsResult
 	rsp, err := apiRequest("/api/v1/targets", "state", "any", cell, restClient)
 	if err != nil {
@@ -1,14 +1,17 @@
 package prometheus
 
-import monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+import monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 
 const (
 	Name     = "prometheus"
 	Version  = "2.37.0"
 	ImageURL = "quay.io/prometheus/prometheus"
+
+	// CredentialsHashAnnotation on the Prometheus pods changes whenever remote-write credentials do
+	CredentialsHashAnnotation = "monitoring.gitpod.io/credentials-hash"
 )
 
-func Labels(cell *monitoringv1alpha1.Cell) map[string]string {
+func Labels(cell *monitoringv1beta1.Cell) map[string]string {
 	c := cell.DeepCopy()
 	labels := c.Labels
 	labels["app.kubernetes.io/name"] = Name
@@ -8,10 +8,18 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 )
 
-func Prometheus(cell *monitoringv1alpha1.Cell) *monitoringv1.Prometheus {
+func Prometheus(cell *monitoringv1beta1.Cell) *monitoringv1.Prometheus {
+	podMetadata := &monitoringv1.EmbeddedObjectMetadata{
+		Labels: Labels(cell),
+	}
+	if cell.Status.CredentialsHash != "" {
+		// Restart Prometheus whenever remote-write credentials are rotated
+		podMetadata.Annotations = map[string]string{CredentialsHashAnnotation: cell.Status.CredentialsHash}
+	}
+
 	return &monitoringv1.Prometheus{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.coreos.com/v1",
@@ -33,11 +41,9 @@ func Prometheus(cell *monitoringv1alpha1.Cell) *monitoringv1.Prometheus {
 		Spec: monitoringv1.PrometheusSpec{
 			RuleSelector: &metav1.LabelSelector{},
 			CommonPrometheusFields: monitoringv1.CommonPrometheusFields{
-				Image: pointer.String(fmt.Sprintf("%s:v%s", ImageURL, Version)),
-				PodMetadata: &monitoringv1.EmbeddedObjectMetadata{
-					Labels: Labels(cell),
-				},
-				Replicas: pointer.Int32(1),
+				Image:       pointer.String(fmt.Sprintf("%s:v%s", ImageURL, Version)),
+				PodMetadata: podMetadata,
+				Replicas:    pointer.Int32(1),
 				SecurityContext: &corev1.PodSecurityContext{
 					FSGroup:      pointer.Int64(2000),
 					RunAsUser:    pointer.Int64(1000),
@@ -48,7 +54,7 @@ func Prometheus(cell *monitoringv1alpha1.Cell) *monitoringv1.Prometheus {
 					"cluster": cell.Spec.ClusterName,
 				},
 				// NodeSelector:           ctx.Config.NodeSelector,
-				// RemoteWrite:            build from spec.Metrics.UpstreamRemoteWrites
+				RemoteWrite:            remoteWrites(cell),
 				Version:                Version,
 				ServiceMonitorSelector: &metav1.LabelSelector{},
 				PodMonitorSelector:     &metav1.LabelSelector{},
@@ -0,0 +1,136 @@
+package prometheus
+
+import (
+	"sort"
+	"strings"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+)
+
+// presets are the queue settings of the remote-write presets. Prometheus' defaults are capacity 2500,
+// 200 shards at most and 500 samples per send.
+var presets = map[monitoringv1beta1.RemoteWritePreset]monitoringv1.QueueConfig{
+	monitoringv1beta1.RemoteWritePresetLowLatency: {
+		Capacity:          2500,
+		MinShards:         2,
+		MaxShards:         50,
+		MaxSamplesPerSend: 500,
+		BatchSendDeadline: "1s",
+		MinBackoff:        "30ms",
+		MaxBackoff:        "1s",
+	},
+	monitoringv1beta1.RemoteWritePresetHighThroughput: {
+		Capacity:          20000,
+		MinShards:         4,
+		MaxShards:         200,
+		MaxSamplesPerSend: 5000,
+		BatchSendDeadline: "10s",
+		MinBackoff:        "100ms",
+		MaxBackoff:        "10s",
+	},
+	monitoringv1beta1.RemoteWritePresetConstrained: {
+		Capacity:          1000,
+		MinShards:         1,
+		MaxShards:         10,
+		MaxSamplesPerSend: 1000,
+		BatchSendDeadline: "5s",
+		MinBackoff:        "100ms",
+		MaxBackoff:        "30s",
+	},
+}
+
+func remoteWrites(cell *monitoringv1beta1.Cell) []monitoringv1.RemoteWriteSpec {
+	var specs []monitoringv1.RemoteWriteSpec
+	for _, rw := range cell.Spec.Metrics.RemoteWrite {
+		spec := *rw.RemoteWriteSpec.DeepCopy()
+		if preset, ok := presets[rw.Preset]; ok {
+			spec.QueueConfig = withPreset(preset, spec.QueueConfig)
+		}
+		spec.WriteRelabelConfigs = writeRelabelConfigs(cell, rw)
+		specs = append(specs, spec)
+	}
+
+	return specs
+}
+
+// writeRelabelConfigs filters the samples sent to an endpoint by its allowList, or the cell's if it has none,
+// and its dropList. Relabel configs of the endpoint itself apply to what's left, before its external labels are added.
+func writeRelabelConfigs(cell *monitoringv1beta1.Cell, rw monitoringv1beta1.RemoteWriteSpec) []monitoringv1.RelabelConfig {
+	var configs []monitoringv1.RelabelConfig
+
+	allowList := rw.AllowList
+	if len(allowList) == 0 {
+		allowList = cell.Spec.Metrics.RemoteWriteAllowList
+	}
+	if len(allowList) > 0 {
+		configs = append(configs, monitoringv1.RelabelConfig{
+			SourceLabels: []monitoringv1.LabelName{"__name__"},
+			Regex:        strings.Join(allowList, "|"),
+			Action:       "keep",
+		})
+	}
+	if len(rw.DropList) > 0 {
+		configs = append(configs, monitoringv1.RelabelConfig{
+			SourceLabels: []monitoringv1.LabelName{"__name__"},
+			Regex:        strings.Join(rw.DropList, "|"),
+			Action:       "drop",
+		})
+	}
+
+	configs = append(configs, rw.WriteRelabelConfigs...)
+
+	names := make([]string, 0, len(rw.ExternalLabels))
+	for name := range rw.ExternalLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		configs = append(configs, monitoringv1.RelabelConfig{
+			TargetLabel: name,
+			Replacement: rw.ExternalLabels[name],
+			Action:      "replace",
+		})
+	}
+
+	return configs
+}
+
+// withPreset overrides the settings of a preset with those set explicitly.
+func withPreset(preset monitoringv1.QueueConfig, explicit *monitoringv1.QueueConfig) *monitoringv1.QueueConfig {
+	q := preset
+	if explicit == nil {
+		return &q
+	}
+
+	if explicit.Capacity != 0 {
+		q.Capacity = explicit.Capacity
+	}
+	if explicit.MinShards != 0 {
+		q.MinShards = explicit.MinShards
+	}
+	if explicit.MaxShards != 0 {
+		q.MaxShards = explicit.MaxShards
+	}
+	if explicit.MaxSamplesPerSend != 0 {
+		q.MaxSamplesPerSend = explicit.MaxSamplesPerSend
+	}
+	if explicit.BatchSendDeadline != "" {
+		q.BatchSendDeadline = explicit.BatchSendDeadline
+	}
+	if explicit.MaxRetries != 0 {
+		q.MaxRetries = explicit.MaxRetries
+	}
+	if explicit.MinBackoff != "" {
+		q.MinBackoff = explicit.MinBackoff
+	}
+	if explicit.MaxBackoff != "" {
+		q.MaxBackoff = explicit.MaxBackoff
+	}
+	if explicit.RetryOnRateLimit {
+		q.RetryOnRateLimit = true
+	}
+
+	return &q
+}
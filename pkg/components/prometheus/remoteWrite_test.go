@@ -0,0 +1,149 @@
+package prometheus_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/components/prometheus"
+)
+
+var _ = Describe("Remote writes", func() {
+	var cell *monitoringv1beta1.Cell
+
+	// remoteWrites renders the remote writes of the cell's Prometheus for the given endpoints
+	remoteWrites := func(endpoints ...monitoringv1beta1.RemoteWriteSpec) []monitoringv1.RemoteWriteSpec {
+		cell.Spec.Metrics.RemoteWrite = endpoints
+		return prometheus.Prometheus(cell).Spec.RemoteWrite
+	}
+	endpoint := func(preset monitoringv1beta1.RemoteWritePreset, queue *monitoringv1.QueueConfig) monitoringv1beta1.RemoteWriteSpec {
+		return monitoringv1beta1.RemoteWriteSpec{
+			RemoteWriteSpec: monitoringv1.RemoteWriteSpec{URL: "https://a.example.com/api/v1/write", QueueConfig: queue},
+			Preset:          preset,
+		}
+	}
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{}
+		cell.Name = "cell"
+		cell.Labels = map[string]string{}
+	})
+
+	Describe("presets", func() {
+		lowLatency := monitoringv1.QueueConfig{
+			Capacity:          2500,
+			MinShards:         2,
+			MaxShards:         50,
+			MaxSamplesPerSend: 500,
+			BatchSendDeadline: "1s",
+			MinBackoff:        "30ms",
+			MaxBackoff:        "1s",
+		}
+
+		DescribeTable("queue settings",
+			func(preset monitoringv1beta1.RemoteWritePreset, explicit *monitoringv1.QueueConfig, expected *monitoringv1.QueueConfig) {
+				Expect(remoteWrites(endpoint(preset, explicit))[0].QueueConfig).To(Equal(expected))
+			},
+			Entry("come from the preset when none are set",
+				monitoringv1beta1.RemoteWritePresetLowLatency, nil, &lowLatency),
+			Entry("are overridden one by one by the ones set explicitly",
+				monitoringv1beta1.RemoteWritePresetLowLatency,
+				&monitoringv1.QueueConfig{MaxShards: 10, MinBackoff: "1s", MaxRetries: 3, RetryOnRateLimit: true},
+				&monitoringv1.QueueConfig{
+					Capacity:          2500,
+					MinShards:         2,
+					MaxShards:         10,
+					MaxSamplesPerSend: 500,
+					BatchSendDeadline: "1s",
+					MaxRetries:        3,
+					MinBackoff:        "1s",
+					MaxBackoff:        "1s",
+					RetryOnRateLimit:  true,
+				}),
+			Entry("are overridden entirely when all are set explicitly",
+				monitoringv1beta1.RemoteWritePresetHighThroughput,
+				&monitoringv1.QueueConfig{
+					Capacity: 1, MinShards: 1, MaxShards: 1, MaxSamplesPerSend: 1,
+					BatchSendDeadline: "2s", MaxRetries: 1, MinBackoff: "1ms", MaxBackoff: "2ms",
+				},
+				&monitoringv1.QueueConfig{
+					Capacity: 1, MinShards: 1, MaxShards: 1, MaxSamplesPerSend: 1,
+					BatchSendDeadline: "2s", MaxRetries: 1, MinBackoff: "1ms", MaxBackoff: "2ms",
+				}),
+			Entry("can't be overridden with zero values, which mean unset",
+				monitoringv1beta1.RemoteWritePresetLowLatency,
+				&monitoringv1.QueueConfig{MinShards: 0, BatchSendDeadline: ""},
+				&lowLatency),
+			Entry("are the explicit ones without a preset",
+				monitoringv1beta1.RemoteWritePreset(""),
+				&monitoringv1.QueueConfig{MaxShards: 10},
+				&monitoringv1.QueueConfig{MaxShards: 10}),
+			Entry("are Prometheus' defaults without a preset or explicit settings",
+				monitoringv1beta1.RemoteWritePreset(""), nil, nil),
+		)
+
+		It("applies to each endpoint on its own", func() {
+			specs := remoteWrites(
+				endpoint(monitoringv1beta1.RemoteWritePresetLowLatency, &monitoringv1.QueueConfig{MaxShards: 10}),
+				endpoint(monitoringv1beta1.RemoteWritePresetLowLatency, nil),
+			)
+			Expect(specs[0].QueueConfig.MaxShards).To(Equal(10))
+			Expect(specs[1].QueueConfig).To(Equal(&lowLatency))
+		})
+
+		It("leaves the Cell's settings unchanged", func() {
+			explicit := &monitoringv1.QueueConfig{MaxShards: 10}
+			remoteWrites(endpoint(monitoringv1beta1.RemoteWritePresetLowLatency, explicit))
+			Expect(explicit).To(Equal(&monitoringv1.QueueConfig{MaxShards: 10}))
+		})
+	})
+
+	Describe("write relabel configs", func() {
+		keep := func(regex string) monitoringv1.RelabelConfig {
+			return monitoringv1.RelabelConfig{SourceLabels: []monitoringv1.LabelName{"__name__"}, Regex: regex, Action: "keep"}
+		}
+		drop := func(regex string) monitoringv1.RelabelConfig {
+			return monitoringv1.RelabelConfig{SourceLabels: []monitoringv1.LabelName{"__name__"}, Regex: regex, Action: "drop"}
+		}
+		label := func(name, value string) monitoringv1.RelabelConfig {
+			return monitoringv1.RelabelConfig{TargetLabel: name, Replacement: value, Action: "replace"}
+		}
+		own := monitoringv1.RelabelConfig{Regex: "pod", Action: "labeldrop"}
+
+		DescribeTable("order",
+			func(cellAllowList []string, rw monitoringv1beta1.RemoteWriteSpec, expected []monitoringv1.RelabelConfig) {
+				cell.Spec.Metrics.RemoteWriteAllowList = cellAllowList
+				rw.URL = "https://a.example.com/api/v1/write"
+				Expect(remoteWrites(rw)[0].WriteRelabelConfigs).To(Equal(expected))
+			},
+			Entry("keeps, then drops, then the endpoint's own, then external labels sorted by name",
+				nil,
+				monitoringv1beta1.RemoteWriteSpec{
+					RemoteWriteSpec: monitoringv1.RemoteWriteSpec{WriteRelabelConfigs: []monitoringv1.RelabelConfig{own}},
+					AllowList:       []string{"up", "gitpod_.*"},
+					DropList:        []string{"gitpod_debug_.*"},
+					ExternalLabels:  map[string]string{"region": "eu", "cluster": "eu01"},
+				},
+				[]monitoringv1.RelabelConfig{keep("up|gitpod_.*"), drop("gitpod_debug_.*"), own, label("cluster", "eu01"), label("region", "eu")}),
+			Entry("keeps by the cell's allowList when the endpoint has none",
+				[]string{"gitpod_.*"},
+				monitoringv1beta1.RemoteWriteSpec{DropList: []string{"gitpod_debug_.*"}},
+				[]monitoringv1.RelabelConfig{keep("gitpod_.*"), drop("gitpod_debug_.*")}),
+			Entry("keeps by the endpoint's allowList over the cell's",
+				[]string{"gitpod_.*"},
+				monitoringv1beta1.RemoteWriteSpec{AllowList: []string{"up"}},
+				[]monitoringv1.RelabelConfig{keep("up")}),
+			Entry("adds external labels after the endpoint's own without any lists",
+				nil,
+				monitoringv1beta1.RemoteWriteSpec{
+					RemoteWriteSpec: monitoringv1.RemoteWriteSpec{WriteRelabelConfigs: []monitoringv1.RelabelConfig{own}},
+					ExternalLabels:  map[string]string{"cluster": "eu01"},
+				},
+				[]monitoringv1.RelabelConfig{own, label("cluster", "eu01")}),
+			Entry("is empty without lists, relabel configs or external labels",
+				nil, monitoringv1beta1.RemoteWriteSpec{}, nil),
+		)
+	})
+})
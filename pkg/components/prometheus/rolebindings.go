@@ -6,12 +6,12 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 )
 
 // extraNamespaceRoleBindings and extraNamespaceRoles are used to give permission to prometheus to scrape metrics
 // from endpoints in other namespaces.
-func RoleBindings(cell *monitoringv1alpha1.Cell) []*rbacv1.RoleBinding {
+func RoleBindings(cell *monitoringv1beta1.Cell) []*rbacv1.RoleBinding {
 	var extraRoleBindings []*rbacv1.RoleBinding
 
 	extraRoleBindings = append(extraRoleBindings,
@@ -23,7 +23,7 @@ func RoleBindings(cell *monitoringv1alpha1.Cell) []*rbacv1.RoleBinding {
 	return extraRoleBindings
 }
 
-func namespacedRolebindingFactory(ns string, cell *monitoringv1alpha1.Cell) *rbacv1.RoleBinding {
+func namespacedRolebindingFactory(ns string, cell *monitoringv1beta1.Cell) *rbacv1.RoleBinding {
 	return &rbacv1.RoleBinding{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "rbac.authorization.k8s.io/v1",
@@ -59,7 +59,7 @@ func namespacedRolebindingFactory(ns string, cell *monitoringv1alpha1.Cell) *rba
 	}
 }
 
-func configRoleBinding(cell *monitoringv1alpha1.Cell) *rbacv1.RoleBinding {
+func configRoleBinding(cell *monitoringv1beta1.Cell) *rbacv1.RoleBinding {
 	return &rbacv1.RoleBinding{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "rbac.authorization.k8s.io/v1",
@@ -6,10 +6,10 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 )
 
-func Roles(cell *monitoringv1alpha1.Cell) []*rbacv1.Role {
+func Roles(cell *monitoringv1beta1.Cell) []*rbacv1.Role {
 	var extraRoles []*rbacv1.Role
 
 	extraRoles = append(extraRoles,
@@ -23,7 +23,7 @@ func Roles(cell *monitoringv1alpha1.Cell) []*rbacv1.Role {
 	return extraRoles
 }
 
-func namespacedRoleFactory(ns string, cell *monitoringv1alpha1.Cell) *rbacv1.Role {
+func namespacedRoleFactory(ns string, cell *monitoringv1beta1.Cell) *rbacv1.Role {
 	return &rbacv1.Role{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "rbac.authorization.k8s.io/v1",
@@ -62,7 +62,7 @@ func namespacedRoleFactory(ns string, cell *monitoringv1alpha1.Cell) *rbacv1.Rol
 	}
 }
 
-func configRole(cell *monitoringv1alpha1.Cell) *rbacv1.Role {
+func configRole(cell *monitoringv1beta1.Cell) *rbacv1.Role {
 	return &rbacv1.Role{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "rbac.authorization.k8s.io/v1",
@@ -91,6 +91,6 @@ func configRole(cell *monitoringv1alpha1.Cell) *rbacv1.Role {
 	}
 }
 
-func configRoleName(cell *monitoringv1alpha1.Cell) string {
+func configRoleName(cell *monitoringv1beta1.Cell) string {
 	return fmt.Sprintf("%s-config", fmt.Sprintf("%s-%s", Name, cell.Name))
 }
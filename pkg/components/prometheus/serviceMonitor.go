@@ -6,10 +6,10 @@ import (
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	monitoringv1alpha1 "github.com/gitpod-io/monitoring-cell/api/v1alpha1"
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
 )
 
-func ServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor {
+func ServiceMonitor(cell *monitoringv1beta1.Cell) *monitoringv1.ServiceMonitor {
 	return &monitoringv1.ServiceMonitor{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "monitoring.coreos.com/v1",
@@ -33,12 +33,12 @@ func ServiceMonitor(cell *monitoringv1alpha1.Cell) *monitoringv1.ServiceMonitor
 				{
 					Port:     "web",
 					Interval: "60s",
-					// MetricRelabelConfigs: drop from spec.Droplist
+					// MetricRelabelConfigs: drop from spec.Metrics.DropList
 				},
 				{
 					Port:     "reloader-web",
 					Interval: "60s",
-					// MetricRelabelConfigs: drop from spec.Droplist
+					// MetricRelabelConfigs: drop from spec.Metrics.DropList
 				},
 			},
 			Selector: metav1.LabelSelector{
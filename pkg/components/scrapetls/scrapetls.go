@@ -0,0 +1,69 @@
+// Package scrapetls lets Prometheus verify the kube-rbac-proxy sidecars it scrapes when the cell's scrapes verify
+// TLS. The sidecars then serve with certificates issued by the cell's CA.
+package scrapetls
+
+import (
+	"fmt"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/pki"
+)
+
+const directory = "/etc/tls/private"
+
+// TLSConfig is how Prometheus checks the certificate of a sidecar. It's verified against the cell's CA by the
+// first DNS name of the certificate when scrapes verify TLS, and isn't verified otherwise.
+func TLSConfig(cell *monitoringv1beta1.Cell, cert pki.Certificate) *monitoringv1.TLSConfig {
+	if cell.Spec.ScrapeTLS.Mode != monitoringv1beta1.ScrapeTLSVerify {
+		return &monitoringv1.TLSConfig{
+			SafeTLSConfig: monitoringv1.SafeTLSConfig{
+				InsecureSkipVerify: true,
+			},
+		}
+	}
+
+	return &monitoringv1.TLSConfig{
+		SafeTLSConfig: monitoringv1.SafeTLSConfig{
+			CA: monitoringv1.SecretOrConfigMap{
+				Secret: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: cert.SecretName},
+					Key:                  "ca.crt",
+				},
+			},
+			ServerName: cert.DNSNames[0],
+		},
+	}
+}
+
+// WithServingCertificate mounts the certificate into the containers of the pod that serve matches, and makes
+// them serve with it, when scrapes verify TLS. kube-rbac-proxy reloads it when it's rotated.
+func WithServingCertificate(cell *monitoringv1beta1.Cell, cert pki.Certificate, spec *corev1.PodSpec, serves func(corev1.Container) bool) {
+	if cell.Spec.ScrapeTLS.Mode != monitoringv1beta1.ScrapeTLSVerify {
+		return
+	}
+
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: "tls",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: cert.SecretName},
+		},
+	})
+	for i := range spec.Containers {
+		container := &spec.Containers[i]
+		if !serves(*container) {
+			continue
+		}
+		container.Args = append(container.Args,
+			fmt.Sprintf("--tls-cert-file=%s/%s", directory, corev1.TLSCertKey),
+			fmt.Sprintf("--tls-private-key-file=%s/%s", directory, corev1.TLSPrivateKeyKey),
+		)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      "tls",
+			MountPath: directory,
+			ReadOnly:  true,
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package scrapetls
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+	"github.com/gitpod-io/monitoring-cell/pkg/pki"
+)
+
+var _ = Describe("Scrape TLS", func() {
+	var cell *monitoringv1beta1.Cell
+	cert := pki.ServiceCertificate("exporter-cell", "monitoring")
+
+	pod := func() *corev1.PodSpec {
+		return &corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "exporter", Args: []string{"--port=8081"}},
+				{Name: "kube-rbac-proxy-main", Args: []string{"--upstream=http://127.0.0.1:8081/"}},
+				{Name: "kube-rbac-proxy-self", Args: []string{"--upstream=http://127.0.0.1:8082/"}},
+			},
+		}
+	}
+	rbacProxies := func(c corev1.Container) bool { return c.Name != "exporter" }
+
+	BeforeEach(func() {
+		cell = &monitoringv1beta1.Cell{}
+	})
+
+	Context("by default", func() {
+		It("skips verifying certificates", func() {
+			Expect(TLSConfig(cell, cert).InsecureSkipVerify).To(BeTrue())
+			Expect(TLSConfig(cell, cert).CA.Secret).To(BeNil())
+		})
+
+		It("leaves the pod as it is", func() {
+			spec := pod()
+			WithServingCertificate(cell, cert, spec, rbacProxies)
+			Expect(spec).To(Equal(pod()))
+		})
+	})
+
+	Context("when scrapes verify TLS", func() {
+		BeforeEach(func() {
+			cell.Spec.ScrapeTLS.Mode = monitoringv1beta1.ScrapeTLSVerify
+		})
+
+		It("verifies against the CA in the certificate's Secret, by the name of the Service", func() {
+			config := TLSConfig(cell, cert)
+			Expect(config.InsecureSkipVerify).To(BeFalse())
+			Expect(config.CA.Secret.Name).To(Equal("exporter-cell-tls"))
+			Expect(config.CA.Secret.Key).To(Equal("ca.crt"))
+			Expect(config.ServerName).To(Equal("exporter-cell.monitoring.svc"))
+		})
+
+		It("makes only the containers serving scrapes serve with the certificate", func() {
+			spec := pod()
+			WithServingCertificate(cell, cert, spec, rbacProxies)
+
+			Expect(spec.Volumes).To(Equal([]corev1.Volume{{
+				Name:         "tls",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "exporter-cell-tls"}},
+			}}))
+			Expect(spec.Containers[0]).To(Equal(pod().Containers[0]))
+			for _, container := range spec.Containers[1:] {
+				Expect(container.Args).To(Equal([]string{
+					container.Args[0],
+					"--tls-cert-file=/etc/tls/private/tls.crt",
+					"--tls-private-key-file=/etc/tls/private/tls.key",
+				}))
+				Expect(container.VolumeMounts).To(Equal([]corev1.VolumeMount{{Name: "tls", MountPath: "/etc/tls/private", ReadOnly: true}}))
+			}
+		})
+	})
+})
@@ -0,0 +1,14 @@
+package scrapetls
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestScrapeTLS(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Scrape TLS Suite")
+}
@@ -0,0 +1,183 @@
+// Package pki issues the certificates a Cell's components serve with, signed by a CA of the Cell's own.
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Certificate is a serving certificate a component needs
+type Certificate struct {
+	// SecretName is the Secret the certificate, its key and the CA are stored in, in the keys tls.crt,
+	// tls.key and ca.crt
+	SecretName string
+	// DNSNames are the names the certificate is valid for. Scrapes verify the first.
+	DNSNames []string
+}
+
+// ServiceCertificate is the certificate of a Service, valid for its names within the cluster. It's kept
+// in the Secret <name>-tls.
+func ServiceCertificate(name, namespace string) Certificate {
+	return Certificate{
+		SecretName: name + "-tls",
+		DNSNames:   []string{fmt.Sprintf("%s.%s.svc", name, namespace), fmt.Sprintf("%s.%s", name, namespace), name},
+	}
+}
+
+// KeyPair is a PEM encoded certificate and its private key
+type KeyPair struct {
+	Cert []byte
+	Key  []byte
+}
+
+// NewCA creates a self-signed CA, valid from now on for the given duration.
+func NewCA(commonName string, now time.Time, validity time.Duration) (*KeyPair, error) {
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	return sign(template, nil, nil)
+}
+
+// Issue creates a serving certificate for the given DNS names, signed by the CA and valid from now on for
+// the given duration.
+func Issue(ca *KeyPair, dnsNames []string, now time.Time, validity time.Duration) (*KeyPair, error) {
+	if len(dnsNames) == 0 {
+		return nil, errors.New("a serving certificate needs at least one DNS name")
+	}
+	caCert, err := ParseCertificate(ca.Cert)
+	if err != nil {
+		return nil, err
+	}
+	caKey, err := parseKey(ca.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:    dnsNames,
+		NotBefore:   now.Add(-time.Minute),
+		NotAfter:    now.Add(validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	return sign(template, caCert, caKey)
+}
+
+// Verify checks that the certificate is signed by the CA, valid for all DNS names and doesn't expire
+// within renewBefore.
+func Verify(cert *KeyPair, caCert []byte, dnsNames []string, now time.Time, renewBefore time.Duration) error {
+	c, err := ParseCertificate(cert.Cert)
+	if err != nil {
+		return err
+	}
+	if _, err := parseKey(cert.Key); err != nil {
+		return err
+	}
+	if !now.Add(renewBefore).Before(c.NotAfter) {
+		return fmt.Errorf("certificate expires at %s", c.NotAfter.Format(time.RFC3339))
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caCert) {
+		return errors.New("no CA certificate found")
+	}
+	for _, name := range dnsNames {
+		if _, err := c.Verify(x509.VerifyOptions{DNSName: name, Roots: roots, CurrentTime: now}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TrustBundle concatenates the CA certificates of the given PEM blocks that are still valid, without
+// duplicates. Serving certificates signed by a replaced CA stay trusted, as long as the bundle includes it.
+func TrustBundle(now time.Time, blocks ...[]byte) []byte {
+	var bundle []byte
+	seen := map[string]bool{}
+	for _, data := range blocks {
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" || seen[string(block.Bytes)] {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil || !cert.IsCA || now.After(cert.NotAfter) {
+				continue
+			}
+			seen[string(block.Bytes)] = true
+			bundle = append(bundle, pem.EncodeToMemory(block)...)
+		}
+	}
+
+	return bundle
+}
+
+// ParseCertificate decodes the first certificate of a PEM block.
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parseKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, errors.New("no PEM encoded EC private key found")
+	}
+
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+// sign creates a key for the template and signs it with the parent, or itself if parent is nil.
+func sign(template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyPair{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
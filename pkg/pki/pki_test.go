@@ -0,0 +1,84 @@
+package pki
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PKI", func() {
+	var (
+		now      = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		dnsNames = ServiceCertificate("node-exporter-cell", "monitoring").DNSNames
+		ca       *KeyPair
+	)
+
+	BeforeEach(func() {
+		var err error
+		ca, err = NewCA("monitoring/cell", now, 365*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("names the Secret and DNS names of a Service's certificate", func() {
+		cert := ServiceCertificate("node-exporter-cell", "monitoring")
+		Expect(cert.SecretName).To(Equal("node-exporter-cell-tls"))
+		Expect(cert.DNSNames).To(Equal([]string{"node-exporter-cell.monitoring.svc", "node-exporter-cell.monitoring", "node-exporter-cell"}))
+	})
+
+	It("issues certificates that verify against the CA for all their names", func() {
+		cert, err := Issue(ca, dnsNames, now, 90*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Verify(cert, ca.Cert, dnsNames, now, 30*24*time.Hour)).To(Succeed())
+		Expect(Verify(cert, ca.Cert, []string{"prometheus-cell.monitoring.svc"}, now, 0)).NotTo(Succeed())
+	})
+
+	It("refuses to issue certificates without names", func() {
+		_, err := Issue(ca, nil, now, time.Hour)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("asks for certificates to be renewed before they expire", func() {
+		cert, err := Issue(ca, dnsNames, now, 90*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Verify(cert, ca.Cert, dnsNames, now.Add(59*24*time.Hour), 30*24*time.Hour)).To(Succeed())
+		Expect(Verify(cert, ca.Cert, dnsNames, now.Add(61*24*time.Hour), 30*24*time.Hour)).NotTo(Succeed())
+	})
+
+	It("doesn't verify certificates against another CA", func() {
+		cert, err := Issue(ca, dnsNames, now, 90*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		other, err := NewCA("monitoring/cell", now, 365*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(Verify(cert, other.Cert, dnsNames, now, 0)).NotTo(Succeed())
+	})
+
+	It("rejects malformed key pairs", func() {
+		Expect(Verify(&KeyPair{Cert: ca.Cert}, ca.Cert, nil, now, 0)).NotTo(Succeed())
+		Expect(Verify(&KeyPair{Cert: []byte("garbage"), Key: ca.Key}, ca.Cert, nil, now, 0)).NotTo(Succeed())
+	})
+
+	It("keeps replaced CAs in the trust bundle until they expire", func() {
+		replacement, err := NewCA("monitoring/cell", now, 365*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := Issue(ca, dnsNames, now, 90*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		bundle := TrustBundle(now, replacement.Cert, ca.Cert, replacement.Cert)
+		Expect(bytes.Count(bundle, []byte("BEGIN CERTIFICATE"))).To(Equal(2))
+		Expect(Verify(cert, bundle, dnsNames, now, 0)).To(Succeed())
+
+		Expect(TrustBundle(now.Add(366*24*time.Hour), bundle)).To(BeEmpty())
+	})
+
+	It("leaves serving certificates out of the trust bundle", func() {
+		cert, err := Issue(ca, dnsNames, now, 90*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(TrustBundle(now, ca.Cert, cert.Cert)).To(Equal(ca.Cert))
+	})
+})
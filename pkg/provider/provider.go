@@ -0,0 +1,91 @@
+// Package provider holds the defaults that differ between the Kubernetes distributions a Cell runs on.
+package provider
+
+import monitoringv1beta1 "github.com/gitpod-io/monitoring-cell/api/v1beta1"
+
+// Profile are the defaults of a provider. How the kubelet is found isn't one of them: prometheus-operator's
+// kubelet endpoint sync keeps the kubelet Service in kube-system up to date from the Node objects, whose
+// addresses and kubelet ports every provider reports.
+type Profile struct {
+	// NodePoolLabel is the node label naming the node pool a node belongs to, if the provider has one
+	NodePoolLabel string
+	// MountPointsExclude are the mount points node-exporter ignores unless the cell picks its own
+	MountPointsExclude string
+	// Unavailable are the Kubernetes components that don't run as pods in kube-system, and why. They aren't
+	// monitored even when enabled.
+	Unavailable map[string]string
+	// Selectors override the labels the pods of Kubernetes components are found by
+	Selectors map[string]map[string]string
+}
+
+const (
+	// managedControlPlane is why the control plane of managed clusters isn't monitored
+	managedControlPlane = "the control plane is managed by the provider"
+	// embedded is why components of k3s aren't monitored
+	embedded = "k3s runs the component embedded in its own process"
+)
+
+var profiles = map[monitoringv1beta1.Provider]Profile{
+	// Node pools are labelled differently by every provider, a generic cluster has no label to go by
+	monitoringv1beta1.ProviderGeneric: {
+		MountPointsExclude: "^/(dev|proc|sys|run/k3s/containerd/.+|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
+	},
+	monitoringv1beta1.ProviderGKE: {
+		NodePoolLabel:      "cloud.google.com/gke-nodepool",
+		MountPointsExclude: "^/(dev|proc|sys|home/kubernetes/containerized_mounter/.+|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
+		Unavailable: map[string]string{
+			"kube-scheduler":          managedControlPlane,
+			"kube-controller-manager": managedControlPlane,
+			"etcd":                    managedControlPlane,
+		},
+		// GKE runs kube-proxy as static pods
+		Selectors: map[string]map[string]string{
+			"kube-proxy": {"component": "kube-proxy"},
+		},
+	},
+	monitoringv1beta1.ProviderEKS: {
+		NodePoolLabel:      "eks.amazonaws.com/nodegroup",
+		MountPointsExclude: "^/(dev|proc|sys|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
+		Unavailable: map[string]string{
+			"kube-scheduler":          managedControlPlane,
+			"kube-controller-manager": managedControlPlane,
+			"etcd":                    managedControlPlane,
+		},
+	},
+	monitoringv1beta1.ProviderAKS: {
+		NodePoolLabel:      "kubernetes.azure.com/agentpool",
+		MountPointsExclude: "^/(dev|proc|sys|var/lib/docker/.+|var/lib/kubelet/pods/.+)($|/)",
+		Unavailable: map[string]string{
+			"kube-scheduler":          managedControlPlane,
+			"kube-controller-manager": managedControlPlane,
+			"etcd":                    managedControlPlane,
+		},
+	},
+	monitoringv1beta1.ProviderK3s: {
+		MountPointsExclude: "^/(dev|proc|sys|run/k3s/containerd/.+|var/lib/rancher/k3s/agent/containerd/.+|var/lib/kubelet/pods/.+)($|/)",
+		Unavailable: map[string]string{
+			"kube-scheduler":          embedded,
+			"kube-controller-manager": embedded,
+			"etcd":                    embedded,
+			"kube-proxy":              embedded,
+		},
+	},
+}
+
+// NodeLabels are the node labels kube-state-metrics exposes unless the cell picks its own.
+func (p Profile) NodeLabels() []string {
+	if p.NodePoolLabel == "" {
+		return []string{"topology.kubernetes.io/region"}
+	}
+
+	return []string{p.NodePoolLabel, "topology.kubernetes.io/region"}
+}
+
+// For returns the profile of the provider the cell runs on.
+func For(cell *monitoringv1beta1.Cell) Profile {
+	if profile, ok := profiles[cell.Spec.Provider]; ok {
+		return profile
+	}
+
+	return profiles[monitoringv1beta1.ProviderGeneric]
+}